@@ -0,0 +1,269 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// APIKeyLocation is where an API key set with WithAPIKey(...) is sent
+type APIKeyLocation int
+
+const (
+	// APIKeyInHeader sends the API key as a header
+	APIKeyInHeader APIKeyLocation = iota
+
+	// APIKeyInQuery sends the API key as a query parameter
+	APIKeyInQuery
+
+	// APIKeyInCookie sends the API key as a cookie
+	APIKeyInCookie
+)
+
+// credential is a secret applied to a Request by one of the auth helpers.
+// Credentials are kept apart from the headers so they can be kept out of
+// logs and debug output, and dropped on cross-origin redirects
+type credential struct {
+	location    APIKeyLocation
+	name, value string
+}
+
+// credentialsKey is the context key holding the credentials applied to an
+// http Request
+type credentialsKey struct{}
+
+// WithBasicAuth sets the username and password used to authenticate every
+// Request created by the Client
+func (c *Client) WithBasicAuth(username, password string) *Client {
+	c.credentials = setCredential(c.credentials, basicAuth(username, password))
+	return c
+}
+
+// WithBearerToken sets the bearer token used to authenticate every Request
+// created by the Client
+func (c *Client) WithBearerToken(token string) *Client {
+	c.credentials = setCredential(c.credentials, bearerToken(token))
+	return c
+}
+
+// WithAPIKey sets an API key, sent as the named header, query parameter or
+// cookie, used to authenticate every Request created by the Client
+func (c *Client) WithAPIKey(name, value string, location APIKeyLocation) *Client {
+	c.credentials = setCredential(c.credentials, credential{location, name, value})
+	return c
+}
+
+// WithBasicAuth sets the username and password used to authenticate the
+// Request, replacing any Authorization set on the Client
+func (r *Request) WithBasicAuth(username, password string) *Request {
+	r.credentials = setCredential(r.credentials, basicAuth(username, password))
+	return r
+}
+
+// WithBearerToken sets the bearer token used to authenticate the Request,
+// replacing any Authorization set on the Client
+func (r *Request) WithBearerToken(token string) *Request {
+	r.credentials = setCredential(r.credentials, bearerToken(token))
+	return r
+}
+
+// WithAPIKey sets an API key, sent as the named header, query parameter or
+// cookie, used to authenticate the Request
+func (r *Request) WithAPIKey(name, value string, location APIKeyLocation) *Request {
+	r.credentials = setCredential(r.credentials, credential{location, name, value})
+	return r
+}
+
+// basicAuth returns the credential for HTTP Basic authentication
+func basicAuth(username, password string) credential {
+	token := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return credential{APIKeyInHeader, "Authorization", "Basic " + token}
+}
+
+// bearerToken returns the credential for bearer token authentication
+func bearerToken(token string) credential {
+	return credential{APIKeyInHeader, "Authorization", "Bearer " + token}
+}
+
+// setCredential returns a copy of the passed credentials with the passed
+// credential added, replacing any sent in the same place
+func setCredential(creds []credential, cred credential) []credential {
+	out := make([]credential, 0, len(creds)+1)
+	for _, c := range creds {
+		if !c.sameTarget(cred) {
+			out = append(out, c)
+		}
+	}
+	return append(out, cred)
+}
+
+// sameTarget reports whether both credentials are sent in the same place
+func (c credential) sameTarget(o credential) bool {
+	if c.location != o.location {
+		return false
+	}
+	if c.location == APIKeyInHeader {
+		return http.CanonicalHeaderKey(c.name) == http.CanonicalHeaderKey(o.name)
+	}
+	return c.name == o.name
+}
+
+// applyCredentials applies the Requests credentials to the passed http
+// Request, recording them in its context
+func (r *Request) applyCredentials(req *http.Request) *http.Request {
+	if len(r.credentials) == 0 {
+		return req
+	}
+	for _, c := range r.credentials {
+		switch c.location {
+		case APIKeyInHeader:
+			req.Header.Set(c.name, c.value)
+		case APIKeyInQuery:
+			q := url.QueryEscape(c.name) + "=" + url.QueryEscape(c.value)
+			if req.URL.RawQuery != "" {
+				q = req.URL.RawQuery + "&" + q
+			}
+			req.URL.RawQuery = q
+		case APIKeyInCookie:
+			req.AddCookie(&http.Cookie{Name: c.name, Value: c.value})
+		}
+	}
+	return req.WithContext(context.WithValue(req.Context(), credentialsKey{}, r.credentials))
+}
+
+// withCredential returns a copy of the passed http Request with the passed
+// credential applied and recorded, so it's redacted and kept from other
+// origins like those set on the Request
+func withCredential(req *http.Request, cred credential) *http.Request {
+	creds := setCredential(credentialsFromRequest(req), cred)
+	req = req.Clone(context.WithValue(req.Context(), credentialsKey{}, creds))
+	req.Header.Set(cred.name, cred.value)
+	return req
+}
+
+// credentialsFromRequest returns the credentials applied to the passed http
+// Request
+func credentialsFromRequest(req *http.Request) []credential {
+	creds, _ := req.Context().Value(credentialsKey{}).([]credential)
+	return creds
+}
+
+// redactCredentials returns copies of the passed http Header and URL with
+// the values of the passed credentials replaced
+func redactCredentials(h http.Header, u *url.URL, creds []credential) (http.Header, *url.URL) {
+	h, u2 := h.Clone(), *u
+	for _, c := range creds {
+		switch c.location {
+		case APIKeyInHeader:
+			if _, ok := h[http.CanonicalHeaderKey(c.name)]; ok {
+				h.Set(c.name, Redacted)
+			}
+		case APIKeyInQuery:
+			u2.RawQuery = replaceQueryParam(u2.RawQuery, c.name, Redacted)
+		case APIKeyInCookie:
+			h["Cookie"] = replaceCookie(h["Cookie"], c.name, Redacted)
+		}
+	}
+	return h, &u2
+}
+
+// stripCredentials removes the passed credentials from the passed http
+// Request
+func stripCredentials(req *http.Request, creds []credential) {
+	for _, c := range creds {
+		switch c.location {
+		case APIKeyInHeader:
+			req.Header.Del(c.name)
+		case APIKeyInQuery:
+			req.URL.RawQuery = replaceQueryParam(req.URL.RawQuery, c.name, "")
+		case APIKeyInCookie:
+			if cookies := replaceCookie(req.Header["Cookie"], c.name, ""); len(cookies) > 0 {
+				req.Header["Cookie"] = cookies
+			} else {
+				req.Header.Del("Cookie")
+			}
+		}
+	}
+}
+
+// replaceQueryParam replaces the values of the named parameter in the passed
+// raw query, removing the parameter entirely when value is empty
+func replaceQueryParam(rawQuery, name, value string) string {
+	parts := strings.Split(rawQuery, "&")
+	out := parts[:0]
+	for _, p := range parts {
+		key, _, _ := strings.Cut(p, "=")
+		if k, err := url.QueryUnescape(key); err == nil && k == name {
+			if value == "" {
+				continue
+			}
+			p = key + "=" + url.QueryEscape(value)
+		}
+		out = append(out, p)
+	}
+	return strings.Join(out, "&")
+}
+
+// replaceCookie replaces the value of the named cookie in the passed Cookie
+// header values, removing the cookie entirely when value is empty
+func replaceCookie(headers []string, name, value string) []string {
+	var out []string
+	for _, h := range headers {
+		var cookies []string
+		for _, c := range strings.Split(h, ";") {
+			c = strings.TrimSpace(c)
+			if n, _, _ := strings.Cut(c, "="); n == name {
+				if value == "" {
+					continue
+				}
+				c = name + "=" + value
+			}
+			if c != "" {
+				cookies = append(cookies, c)
+			}
+		}
+		if len(cookies) > 0 {
+			out = append(out, strings.Join(cookies, "; "))
+		}
+	}
+	return out
+}
+
+// errTooManyRedirects matches the error the http Client returns after
+// following 10 redirects
+var errTooManyRedirects = errors.New("stopped after 10 redirects")
+
+// checkRedirect returns an http Client CheckRedirect func that drops the
+// passed credentials when redirected to another origin, before deferring to
+// the passed CheckRedirect (or the default policy when nil)
+func checkRedirect(creds []credential, next func(*http.Request, []*http.Request) error) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if origin(req.URL) != origin(via[0].URL) {
+			stripCredentials(req, creds)
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= 10 {
+			return errTooManyRedirects
+		}
+		return nil
+	}
+}
+
+// origin returns the scheme, host and port of the passed URL
+func origin(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "http":
+			port = "80"
+		case "https":
+			port = "443"
+		}
+	}
+	return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Hostname()) + ":" + port
+}
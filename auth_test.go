@@ -0,0 +1,216 @@
+package httpclient
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAuth_Apply(t *testing.T) {
+	tests := []struct {
+		name    string
+		request func(c *Client) *Request
+		header  string
+		want    string
+	}{
+		{
+			name:    "basic",
+			request: func(c *Client) *Request { return c.Get("/").WithBasicAuth("user", "pass") },
+			header:  "Authorization",
+			want:    "Basic dXNlcjpwYXNz",
+		},
+		{
+			name:    "bearer",
+			request: func(c *Client) *Request { return c.Get("/").WithBearerToken("token") },
+			header:  "Authorization",
+			want:    "Bearer token",
+		},
+		{
+			name:    "client bearer",
+			request: func(c *Client) *Request { return c.WithBearerToken("token").Get("/") },
+			header:  "Authorization",
+			want:    "Bearer token",
+		},
+		{
+			name: "request overrides client",
+			request: func(c *Client) *Request {
+				return c.WithBearerToken("client").Get("/").WithBasicAuth("user", "pass")
+			},
+			header: "Authorization",
+			want:   "Basic dXNlcjpwYXNz",
+		},
+		{
+			name: "overrides plain header",
+			request: func(c *Client) *Request {
+				return c.WithHeader("Authorization", "plain").Get("/").WithBearerToken("token")
+			},
+			header: "Authorization",
+			want:   "Bearer token",
+		},
+		{
+			name:    "api key header",
+			request: func(c *Client) *Request { return c.Get("/").WithAPIKey("X-API-Key", "secret", APIKeyInHeader) },
+			header:  "X-API-Key",
+			want:    "secret",
+		},
+		{
+			name:    "api key query",
+			request: func(c *Client) *Request { return c.Get("/?a=b").WithAPIKey("api key", "s&cret", APIKeyInQuery) },
+			header:  "query",
+			want:    "a=b&api+key=s%26cret",
+		},
+		{
+			name:    "api key cookie",
+			request: func(c *Client) *Request { return c.Get("/").WithAPIKey("session", "secret", APIKeyInCookie) },
+			header:  "Cookie",
+			want:    "session=secret",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.header == "query" {
+					w.Write([]byte(r.URL.RawQuery))
+					return
+				}
+				w.Write([]byte(r.Header.Get(tt.header)))
+			}))
+			defer srv.Close()
+
+			got, err := tt.request(New().WithBaseURL(srv.URL)).String()
+			if err != nil {
+				t.Fatalf("Request.String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("%s = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuth_ClientRequestIsolation(t *testing.T) {
+	c := New().WithBaseURL("http://example.com").WithBearerToken("client")
+	r := c.Get("/").WithAPIKey("key", "secret", APIKeyInQuery)
+	if len(c.credentials) != 1 {
+		t.Errorf("Client credentials = %v, want only the bearer token", c.credentials)
+	}
+	if len(r.credentials) != 2 {
+		t.Errorf("Request credentials = %v, want the bearer token and API key", r.credentials)
+	}
+}
+
+func TestAuth_Redacted(t *testing.T) {
+	var logs bytes.Buffer
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	r := New().WithBaseURL(srv.URL).
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil)), LogConfig{}).
+		Get("/").WithExpectedStatus(http.StatusOK).
+		WithAPIKey("X-API-Key", "header-secret", APIKeyInHeader).
+		WithAPIKey("key", "query-secret", APIKeyInQuery).
+		WithAPIKey("session", "cookie-secret", APIKeyInCookie)
+
+	curl, err := r.Curl()
+	if err != nil {
+		t.Fatalf("Request.Curl() error = %v", err)
+	}
+	_, err = r.String()
+	if err == nil {
+		t.Fatal("Request.String() error = nil, want a StatusError")
+	}
+
+	for name, out := range map[string]string{"curl": curl, "log": logs.String(), "error": err.Error()} {
+		for _, secret := range []string{"header-secret", "query-secret", "cookie-secret"} {
+			if strings.Contains(out, secret) {
+				t.Errorf("%s output contains %q: %s", name, secret, out)
+			}
+		}
+	}
+	if !strings.Contains(curl, "session="+Redacted) {
+		t.Errorf("curl = %s, want the cookie redacted", curl)
+	}
+}
+
+func TestAuth_RedactedConnectionError(t *testing.T) {
+	var logs bytes.Buffer
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Close() // Refuse connections
+
+	err := New().WithBaseURL(srv.URL).
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil)), LogConfig{}).
+		Get("/x").
+		WithAPIKey("api_key", "query-secret", APIKeyInQuery).
+		Error()
+	if err == nil {
+		t.Fatal("Request.Error() error = nil, want a connection error")
+	}
+	for name, out := range map[string]string{"log": logs.String(), "error": err.Error()} {
+		if strings.Contains(out, "query-secret") {
+			t.Errorf("%s output contains the API key: %s", name, out)
+		}
+	}
+	if !strings.Contains(err.Error(), "api_key=") {
+		t.Errorf("Request.Error() error = %v, want the redacted URL", err)
+	}
+}
+
+func TestAuth_Redirect(t *testing.T) {
+	type seen struct{ auth, key, query, cookie string }
+	record := func(got *seen) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			*got = seen{r.Header.Get("Authorization"), r.Header.Get("X-API-Key"), r.URL.Query().Get("key"), ""}
+			if c, err := r.Cookie("session"); err == nil {
+				got.cookie = c.Value
+			}
+		}
+	}
+
+	var other seen
+	otherSrv := httptest.NewServer(record(&other))
+	defer otherSrv.Close()
+
+	var same seen
+	mux := http.NewServeMux()
+	mux.HandleFunc("/same", record(&same))
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		to := otherSrv.URL
+		if r.URL.Query().Get("to") == "same" {
+			to = "/same"
+		}
+		http.Redirect(w, r, to+"?"+r.URL.RawQuery, http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		to   string
+		got  *seen
+		want seen
+	}{
+		{"same origin", "same", &same, seen{"Bearer token", "secret", "secret", "secret"}},
+		{"cross origin", "other", &other, seen{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New().WithBaseURL(srv.URL).WithBearerToken("token").
+				Get("/redirect?to="+tt.to).
+				WithAPIKey("X-API-Key", "secret", APIKeyInHeader).
+				WithAPIKey("key", "secret", APIKeyInQuery).
+				WithAPIKey("session", "secret", APIKeyInCookie).
+				Error()
+			if err != nil {
+				t.Fatalf("Request.Error() error = %v", err)
+			}
+			if *tt.got != tt.want {
+				t.Errorf("redirected request had %+v, want %+v", *tt.got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package httpclient
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// ConstantBackoff returns a backoff.BackOff that always waits the passed
+// interval between retries
+func ConstantBackoff(interval time.Duration) backoff.BackOff {
+	return backoff.NewConstantBackOff(interval)
+}
+
+// LinearBackoff returns a backoff.BackOff that waits the initial interval
+// before the first retry and increment longer before every retry after it,
+// never waiting longer than max (when max is greater than zero)
+func LinearBackoff(initial, increment, max time.Duration) backoff.BackOff {
+	return &linearBackoff{initial: initial, increment: increment, max: max}
+}
+
+// ExponentialBackoff returns a backoff.BackOff that multiplies the interval
+// waited between retries by multiplier after every retry, starting at initial
+// and never exceeding max. Every interval is randomized by the jitter factor
+// (0 disables jitter, 0.5 waits anywhere between 50% and 150% of the
+// interval) and retries stop once maxElapsed has passed (0 never stops)
+func ExponentialBackoff(initial, max, maxElapsed time.Duration, multiplier, jitter float64) backoff.BackOff {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = initial
+	b.MaxInterval = max
+	b.MaxElapsedTime = maxElapsed
+	b.Multiplier = multiplier
+	b.RandomizationFactor = jitter
+	b.Reset()
+	return b
+}
+
+// DecorrelatedJitterBackoff returns a backoff.BackOff implementing the
+// "decorrelated jitter" algorithm, where every interval is a random duration
+// between base and three times the previous interval, capped at max (when
+// max is greater than zero)
+func DecorrelatedJitterBackoff(base, max time.Duration) backoff.BackOff {
+	return &decorrelatedJitterBackoff{base: base, max: max, prev: base}
+}
+
+// linearBackoff is a backoff.BackOff that increases linearly
+type linearBackoff struct {
+	initial, increment, max time.Duration
+	retries                 int
+}
+
+// NextBackOff returns the duration to wait before the next retry
+func (b *linearBackoff) NextBackOff() time.Duration {
+	next := b.initial + time.Duration(b.retries)*b.increment
+	b.retries++
+	if b.max > 0 && next > b.max {
+		return b.max
+	}
+	return next
+}
+
+// Reset restarts the backoff from the initial interval
+func (b *linearBackoff) Reset() { b.retries = 0 }
+
+// clone returns a reset copy of the backoff
+func (b *linearBackoff) clone() backoff.BackOff {
+	return &linearBackoff{initial: b.initial, increment: b.increment, max: b.max}
+}
+
+// decorrelatedJitterBackoff is a backoff.BackOff implementing decorrelated
+// jitter
+type decorrelatedJitterBackoff struct {
+	base, max, prev time.Duration
+}
+
+// NextBackOff returns the duration to wait before the next retry
+func (b *decorrelatedJitterBackoff) NextBackOff() time.Duration {
+	next := b.base
+	upper := 3 * b.prev
+	if b.prev > math.MaxInt64/3 {
+		upper = math.MaxInt64 // Don't overflow when uncapped
+	}
+	if upper > b.base {
+		next += time.Duration(rand.Int63n(int64(upper - b.base)))
+	}
+	if b.max > 0 && next > b.max {
+		next = b.max
+	}
+	b.prev = next
+	return next
+}
+
+// Reset restarts the backoff from the base interval
+func (b *decorrelatedJitterBackoff) Reset() { b.prev = b.base }
+
+// clone returns a reset copy of the backoff
+func (b *decorrelatedJitterBackoff) clone() backoff.BackOff {
+	return &decorrelatedJitterBackoff{base: b.base, max: b.max, prev: b.base}
+}
+
+// newBackoff returns a fresh backoff.BackOff for a single execution of a
+// Request, so that one configured on a Client can be shared by all of its
+// Requests. BackOffs that can't be copied are reset and used as they are
+func newBackoff(b backoff.BackOff, clock Clock) backoff.BackOff {
+	switch b := b.(type) {
+	case nil:
+		next := backoff.NewExponentialBackOff()
+		next.Clock = clock
+		next.Reset()
+		return next
+	case *backoff.ExponentialBackOff:
+		next := *b
+		next.Clock = clock
+		next.Reset()
+		return &next
+	case interface{ clone() backoff.BackOff }:
+		return b.clone()
+	default:
+		b.Reset()
+		return b
+	}
+}
@@ -0,0 +1,127 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+func TestBackoffStrategies(t *testing.T) {
+	tests := []struct {
+		name string
+		b    backoff.BackOff
+		want []time.Duration
+	}{
+		{
+			name: "constant",
+			b:    ConstantBackoff(time.Second),
+			want: []time.Duration{time.Second, time.Second, time.Second},
+		},
+		{
+			name: "linear",
+			b:    LinearBackoff(time.Second, 2*time.Second, 4*time.Second),
+			want: []time.Duration{time.Second, 3 * time.Second, 4 * time.Second},
+		},
+		{
+			name: "exponential without jitter",
+			b:    ExponentialBackoff(time.Second, 5*time.Second, 0, 2, 0),
+			want: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []time.Duration
+			for range tt.want {
+				got = append(got, tt.b.NextBackOff())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NextBackOff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	base, max := 10*time.Millisecond, time.Second
+	b := DecorrelatedJitterBackoff(base, max)
+	prev := base
+	for i := 0; i < 100; i++ {
+		got := b.NextBackOff()
+		if got < base || got > max || got > 3*prev {
+			t.Fatalf("NextBackOff() = %v, want between %v and %v", got, base, 3*prev)
+		}
+		prev = got
+	}
+
+	// A max of zero leaves the intervals uncapped, as with LinearBackoff
+	b = DecorrelatedJitterBackoff(base, 0)
+	for i := 0; i < 100; i++ {
+		if got := b.NextBackOff(); got < base {
+			t.Fatalf("NextBackOff() without a max = %v, want at least %v", got, base)
+		}
+	}
+}
+
+func TestNewBackoff_Copies(t *testing.T) {
+	shared := LinearBackoff(time.Second, time.Second, 0)
+	a, b := newBackoff(shared, SystemClock), newBackoff(shared, SystemClock)
+	a.NextBackOff()
+	a.NextBackOff()
+	if got := b.NextBackOff(); got != time.Second {
+		t.Errorf("NextBackOff() on a fresh copy = %v, want %v", got, time.Second)
+	}
+	if got := shared.NextBackOff(); got != time.Second {
+		t.Errorf("NextBackOff() on the shared backoff = %v, want %v", got, time.Second)
+	}
+}
+
+func TestRequest_WithBackoff(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	err := New().WithBaseURL(srv.URL).
+		WithClock(clock).
+		WithBackoff(LinearBackoff(time.Second, time.Second, 0)).
+		Get("/").
+		WithExpectedStatus(http.StatusOK).
+		WithRetry(4).
+		Error()
+	if err == nil {
+		t.Fatal("Request.Error() expected an error")
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	if !reflect.DeepEqual(clock.sleeps, want) {
+		t.Errorf("Request.Error() slept %v, want %v", clock.sleeps, want)
+	}
+}
+
+// fakeClock is a Clock that advances instantly when slept and records the
+// duration of every sleep
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.sleeps = append(c.sleeps, d)
+	return ctx.Err()
+}
@@ -0,0 +1,143 @@
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// DefaultBodyBufferLimit is the maximum number of bytes of a non-seekable
+// body that will be buffered so it can be resent on retries and redirects,
+// unless another limit has been set with WithBodyBufferLimit(...)
+var DefaultBodyBufferLimit int64 = 10 << 20 // 10 MiB
+
+// setBody sets the Requests body on the passed http Request. Whenever the
+// body can be replayed GetBody is also set so that the body can be resent on
+// retries and redirects
+func (r *Request) setBody(req *http.Request) error {
+	if r.body == nil {
+		return nil
+	}
+
+	// Streams are sent as they are and can never be replayed
+	if r.streamBody {
+		req.Body = ioutil.NopCloser(r.body)
+		req.ContentLength = -1
+		return nil
+	}
+
+	switch body := r.body.(type) {
+	case *bytes.Buffer:
+		// Replay the unread portion of the buffer without draining it
+		return setBytesBody(req, body.Bytes())
+	case io.ReadSeeker:
+		return setSeekerBody(req, body)
+	}
+
+	// Buffer the body up to the limit, streaming it if it's any larger
+	buffered, err := r.bufferBody()
+	if err != nil {
+		return err
+	}
+	if buffered {
+		return setBytesBody(req, r.body.(*bytes.Buffer).Bytes())
+	}
+	req.Body = ioutil.NopCloser(r.body)
+	req.ContentLength = -1
+	return nil
+}
+
+// bufferBody reads a body that's neither a bytes Buffer nor an io.ReadSeeker
+// into a bytes Buffer, so the Request can be built again, reporting whether
+// the body can be replayed. Bodies over the buffer limit are left unread
+func (r *Request) bufferBody() (bool, error) {
+	if r.streamBody {
+		return false, nil
+	}
+	switch r.body.(type) {
+	case nil, *bytes.Buffer, io.ReadSeeker:
+		return true, nil
+	}
+	limit := r.bodyLimit
+	if limit <= 0 {
+		limit = DefaultBodyBufferLimit
+	}
+	buf, err := ioutil.ReadAll(io.LimitReader(r.body, limit+1))
+	if err != nil {
+		return false, err
+	}
+	if int64(len(buf)) > limit {
+		// Put back what was read so the whole body is still sent
+		r.body = io.MultiReader(bytes.NewReader(buf), r.body)
+		return false, nil
+	}
+	r.body = bytes.NewBuffer(buf)
+	return true, nil
+}
+
+// setBytesBody sets the passed bytes as a replayable body on the passed http
+// Request
+func setBytesBody(req *http.Request, b []byte) error {
+	if len(b) == 0 {
+		return setEmptyBody(req)
+	}
+	req.ContentLength = int64(len(b))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(b)), nil
+	}
+	req.Body, _ = req.GetBody()
+	return nil
+}
+
+// setSeekerBody sets the passed io.ReadSeeker as a replayable body on the
+// passed http Request, replaying it from its current offset
+func setSeekerBody(req *http.Request, body io.ReadSeeker) error {
+	start, err := body.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	end, err := body.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if end == start {
+		return setEmptyBody(req)
+	}
+	req.ContentLength = end - start
+	req.GetBody = func() (io.ReadCloser, error) {
+		if _, err := body.Seek(start, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(body), nil
+	}
+	req.Body, err = req.GetBody()
+	return err
+}
+
+// setEmptyBody explicitly sets an empty body on the passed http Request
+func setEmptyBody(req *http.Request) error {
+	req.Body = http.NoBody
+	req.GetBody = func() (io.ReadCloser, error) { return http.NoBody, nil }
+	return nil
+}
+
+// replayable reports whether the passed http Request can be sent again
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rewind returns a copy of the passed http Request with a fresh body ready to
+// be sent again
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	next := req.Clone(req.Context())
+	next.Body = body
+	return next, nil
+}
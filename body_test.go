@@ -0,0 +1,143 @@
+package httpclient
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequest_ReplayableBody(t *testing.T) {
+	tmp, err := ioutil.TempFile(t.TempDir(), "body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tmp.Close()
+	if _, err := tmp.WriteString("file body"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		build     func(*Request) *Request
+		want      string
+		wantCalls int
+	}{
+		{
+			name:      "string",
+			build:     func(r *Request) *Request { return r.WithString("hello") },
+			want:      "hello",
+			wantCalls: 2,
+		},
+		{
+			name:      "json",
+			build:     func(r *Request) *Request { return r.WithJSON(map[string]int{"a": 1}) },
+			want:      "{\"a\":1}\n",
+			wantCalls: 2,
+		},
+		{
+			name:      "seeker",
+			build:     func(r *Request) *Request { return r.WithBody(tmp) },
+			want:      "file body",
+			wantCalls: 2,
+		},
+		{
+			name:      "buffered stream",
+			build:     func(r *Request) *Request { return r.WithBody(readWriter("buffered")) },
+			want:      "buffered",
+			wantCalls: 2,
+		},
+		{
+			name: "stream over buffer limit",
+			build: func(r *Request) *Request {
+				return r.WithBody(readWriter("too large")).WithBodyBufferLimit(3)
+			},
+			want:      "too large",
+			wantCalls: 1,
+		},
+		{
+			name:      "explicit stream",
+			build:     func(r *Request) *Request { return r.WithStream(strings.NewReader("stream")) },
+			want:      "stream",
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodies []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				bodies = append(bodies, string(b))
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer srv.Close()
+
+			res, err := tt.build(New().WithBaseURL(srv.URL).WithClock(&fakeClock{}).Post("/")).
+				WithRetryPolicy(RetryOnServerError).
+				WithRetry(2).
+				Do()
+			if err != nil {
+				t.Fatalf("Request.Do() error = %v", err)
+			}
+			res.Close()
+			if len(bodies) != tt.wantCalls {
+				t.Fatalf("Request.Do() calls = %v, want %v", len(bodies), tt.wantCalls)
+			}
+			for i, b := range bodies {
+				if b != tt.want {
+					t.Errorf("Request.Do() attempt %v body = %q, want %q", i+1, b, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRequest_ReplayableBody_Redirect(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{name: "temporary redirect", status: http.StatusTemporaryRedirect},
+		{name: "permanent redirect", status: http.StatusPermanentRedirect},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			mux := http.NewServeMux()
+			mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "/new", tt.status)
+			})
+			mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				got = string(b)
+			})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			err := New().WithBaseURL(srv.URL).
+				Put("/old").
+				WithBody(readWriter("payload")).
+				WithExpectedStatus(http.StatusOK).
+				Error()
+			if err != nil {
+				t.Fatalf("Request.Error() error = %v", err)
+			}
+			if got != "payload" {
+				t.Errorf("redirected body = %q, want %q", got, "payload")
+			}
+		})
+	}
+}
+
+// readWriter returns a non-seekable io.ReadWriter containing the passed string
+func readWriter(s string) io.ReadWriter {
+	return struct {
+		io.Reader
+		io.Writer
+	}{strings.NewReader(s), ioutil.Discard}
+}
@@ -0,0 +1,89 @@
+package httpclient
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrRetryBudgetExhausted is returned when a Request gives up retrying
+// because its Clients RetryBudget has been spent
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// RetryBudget is a token bucket shared by all Requests created by a Client
+// that limits how many retries they may perform in total. Every Request
+// deposits a fraction of a token and every retry withdraws a whole one, so
+// that when a dependency goes down retries can't multiply the load on it
+type RetryBudget struct {
+	mu           sync.Mutex
+	ratio        float64
+	minPerSecond float64
+	window       time.Duration
+	buckets      []budgetBucket
+}
+
+// budgetBucket counts the requests and retries made in a single second
+type budgetBucket struct {
+	second              int64
+	requests, withdrawn int
+}
+
+// NewRetryBudget creates a RetryBudget allowing retries of up to ratio of the
+// requests (0.1 being 10%) made within the trailing window, plus
+// minPerSecond retries per second regardless of how many requests were made
+func NewRetryBudget(ratio, minPerSecond float64, window time.Duration) *RetryBudget {
+	seconds := int(math.Ceil(window.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return &RetryBudget{
+		ratio:        ratio,
+		minPerSecond: minPerSecond,
+		window:       time.Duration(seconds) * time.Second,
+		buckets:      make([]budgetBucket, seconds),
+	}
+}
+
+// deposit records that a request was made at the passed time
+func (b *RetryBudget) deposit(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bucket(now).requests++
+}
+
+// withdraw attempts to spend a token for a retry made at the passed time,
+// returning whether there was one to spend
+func (b *RetryBudget) withdraw(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.balance(now) < 1 {
+		return false
+	}
+	b.bucket(now).withdrawn++
+	return true
+}
+
+// balance returns the number of tokens available at the passed time
+func (b *RetryBudget) balance(now time.Time) float64 {
+	var requests, withdrawn int
+	oldest := now.Unix() - int64(len(b.buckets))
+	for _, bk := range b.buckets {
+		if bk.second > oldest {
+			requests += bk.requests
+			withdrawn += bk.withdrawn
+		}
+	}
+	return b.ratio*float64(requests) + b.minPerSecond*b.window.Seconds() - float64(withdrawn)
+}
+
+// bucket returns the bucket for the passed time, clearing it if it was last
+// used for an earlier second
+func (b *RetryBudget) bucket(now time.Time) *budgetBucket {
+	second := now.Unix()
+	bk := &b.buckets[second%int64(len(b.buckets))]
+	if bk.second != second {
+		*bk = budgetBucket{second: second}
+	}
+	return bk
+}
@@ -0,0 +1,75 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryBudget(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		ratio        float64
+		minPerSecond float64
+		requests     int
+		after        time.Duration // How long after the requests to retry
+		want         int           // Number of retries allowed
+	}{
+		{name: "ratio of requests", ratio: 0.1, requests: 50, want: 5},
+		{name: "minimum rate", minPerSecond: 1, requests: 0, want: 10},
+		{name: "ratio and minimum rate", ratio: 0.5, minPerSecond: 0.1, requests: 4, want: 3},
+		{name: "requests outside the window", ratio: 0.1, requests: 50, after: 11 * time.Second, want: 0},
+		{name: "nothing to spend", requests: 100, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewRetryBudget(tt.ratio, tt.minPerSecond, 10*time.Second)
+			for i := 0; i < tt.requests; i++ {
+				b.deposit(now)
+			}
+			got := 0
+			for b.withdraw(now.Add(tt.after)) {
+				got++
+			}
+			if got != tt.want {
+				t.Errorf("RetryBudget allowed %v retries, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_WithRetryBudget(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := New().WithBaseURL(srv.URL).
+		WithClock(&fakeClock{now: time.Now()}).
+		WithRetryPolicy(RetryOnServerError).
+		WithRetryBudget(NewRetryBudget(0, 0.1, 10*time.Second))
+
+	// The first request may spend the single token from the minimum rate
+	_, err := c.Get("/").WithRetry(5).Do()
+	if !errors.Is(err, ErrRetryBudgetExhausted) {
+		t.Fatalf("Request.Do() error = %v, want %v", err, ErrRetryBudgetExhausted)
+	}
+	if calls != 2 {
+		t.Errorf("Request.Do() calls = %v, want 2", calls)
+	}
+
+	// The second request gives up after its first attempt
+	calls = 0
+	_, err = c.Get("/").WithRetry(5).Do()
+	if !errors.Is(err, ErrRetryBudgetExhausted) {
+		t.Fatalf("Request.Do() error = %v, want %v", err, ErrRetryBudgetExhausted)
+	}
+	if calls != 1 {
+		t.Errorf("Request.Do() calls = %v, want 1", calls)
+	}
+}
@@ -0,0 +1,154 @@
+package cassette
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/s32x/httpclient"
+)
+
+func TestTransport_RecordAndReplay(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		b, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Tenant", r.Header.Get("X-Tenant"))
+		w.Write([]byte(r.Method + " " + r.URL.Path + " " + string(b)))
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	run := func(tr *Transport) []string {
+		c := httpclient.New().
+			WithBaseURL(srv.URL).
+			WithTransport(tr).
+			WithHeader("Authorization", "Bearer secret")
+		var out []string
+		for _, r := range []*httpclient.Request{
+			c.Get("/users").WithHeader("X-Tenant", "a"),
+			c.Get("/users").WithHeader("X-Tenant", "b"),
+			c.Post("/users").WithString("gopher"),
+		} {
+			s, err := r.String()
+			if err != nil {
+				t.Fatalf("Request.String() error = %v", err)
+			}
+			out = append(out, s)
+		}
+		return out
+	}
+
+	// Record the interactions against the server
+	rec, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.WithRedactors(RedactHeaders())
+	recorded := run(rec)
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Transport.Save() error = %v", err)
+	}
+	b, _ := os.ReadFile(path)
+	if strings.Contains(string(b), "Bearer secret") {
+		t.Errorf("cassette contains a redacted header:\n%s", b)
+	}
+
+	// Replay them without touching the server
+	calls = 0
+	replay, err := New(path, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay.WithMatchers(append(DefaultMatchers, MatchHeaders("X-Tenant"))...)
+	replayed := run(replay)
+	if calls != 0 {
+		t.Errorf("replay made %v calls to the server, want 0", calls)
+	}
+	for i := range recorded {
+		if recorded[i] != replayed[i] {
+			t.Errorf("replayed %q, want %q", replayed[i], recorded[i])
+		}
+	}
+}
+
+func TestTransport_RecordAndReplay_Credentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	run := func(tr *Transport) (string, error) {
+		return httpclient.New().
+			WithBaseURL(srv.URL).
+			WithTransport(tr).
+			WithAPIKey("k", "query-secret", httpclient.APIKeyInQuery).
+			WithAPIKey("sid", "cookie-secret", httpclient.APIKeyInCookie).
+			Get("/users").
+			String()
+	}
+
+	// Credentials are redacted without any Redactors
+	rec, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := run(rec)
+	if err != nil {
+		t.Fatalf("Request.String() error = %v", err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Transport.Save() error = %v", err)
+	}
+	b, _ := os.ReadFile(path)
+	for _, secret := range []string{"query-secret", "cookie-secret"} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("cassette contains %s:\n%s", secret, b)
+		}
+	}
+
+	// The redacted interaction still matches on replay
+	replay, err := New(path, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay.WithMatchers(append(DefaultMatchers, MatchHeaders("Cookie"))...)
+	replayed, err := run(replay)
+	if err != nil {
+		t.Fatalf("Request.String() error = %v", err)
+	}
+	if replayed != recorded {
+		t.Errorf("replayed %q, want %q", replayed, recorded)
+	}
+}
+
+func TestTransport_ReplayUnmatched(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	c := &Cassette{Interactions: []Interaction{{
+		Request:  Request{Method: http.MethodGet, URL: "http://example.com/users"},
+		Response: Response{StatusCode: http.StatusOK, Body: Body{0xff, 0x00}},
+	}}}
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	tr, err := New(path, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := httpclient.New().WithBaseURL("http://example.com").WithTransport(tr)
+
+	b, err := client.Get("/users").Bytes()
+	if err != nil || string(b) != "\xff\x00" {
+		t.Errorf("Request.Bytes() = %q, %v, want the recorded binary body", b, err)
+	}
+
+	_, err = client.Post("/users").WithString("x").Do()
+	if err == nil || !strings.Contains(err.Error(), "no interaction matches POST http://example.com/users") {
+		t.Errorf("Request.Do() error = %v, want an unmatched interaction error", err)
+	}
+}
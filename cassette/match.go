@@ -0,0 +1,62 @@
+package cassette
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+
+	"github.com/s32x/httpclient"
+)
+
+// Matcher reports whether an http Request (and its body) matches a recorded
+// Request
+type Matcher func(req *http.Request, body []byte, recorded Request) bool
+
+// DefaultMatchers match requests by method, URL and body
+var DefaultMatchers = []Matcher{MatchMethod, MatchURL, MatchBody}
+
+// MatchMethod matches requests with the same method
+func MatchMethod(req *http.Request, _ []byte, recorded Request) bool {
+	return req.Method == recorded.Method
+}
+
+// MatchURL matches requests with the same URL, once any credentials in it
+// have been redacted as they are when recorded
+func MatchURL(req *http.Request, _ []byte, recorded Request) bool {
+	_, u := httpclient.RedactCredentials(req)
+	return u.String() == recorded.URL
+}
+
+// MatchBody matches requests with the same body
+func MatchBody(_ *http.Request, body []byte, recorded Request) bool {
+	return bytes.Equal(body, recorded.Body)
+}
+
+// MatchHeaders returns a Matcher that matches requests with the same values
+// for all of the passed headers, once any credentials in them have been
+// redacted as they are when recorded
+func MatchHeaders(keys ...string) Matcher {
+	return func(req *http.Request, _ []byte, recorded Request) bool {
+		header, _ := httpclient.RedactCredentials(req)
+		for _, key := range keys {
+			if !reflect.DeepEqual(header.Values(key), recorded.Header.Values(key)) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Redactor modifies an Interaction before it is saved, allowing secrets to be
+// removed from cassettes
+type Redactor func(*Interaction)
+
+// RedactHeaders returns a Redactor that replaces the values of all
+// httpclient.SensitiveHeaders and the passed headers in both the request and
+// the response
+func RedactHeaders(keys ...string) Redactor {
+	return func(i *Interaction) {
+		i.Request.Header = httpclient.RedactHeader(i.Request.Header, keys...)
+		i.Response.Header = httpclient.RedactHeader(i.Response.Header, keys...)
+	}
+}
@@ -0,0 +1,193 @@
+package cassette
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/s32x/httpclient"
+	"github.com/s32x/httpclient/internal/replay"
+)
+
+// Mode is the mode a Transport operates in
+type Mode int
+
+const (
+	// ModeReplay answers requests from the cassette, failing any that weren't
+	// recorded
+	ModeReplay Mode = iota
+
+	// ModeRecord performs requests against the real server and records them
+	// to the cassette
+	ModeRecord
+)
+
+// Transport is an http RoundTripper that records traffic to, or replays it
+// from, a cassette file
+type Transport struct {
+	path      string
+	mode      Mode
+	next      http.RoundTripper
+	matchers  []Matcher
+	redactors []Redactor
+
+	mu       sync.Mutex
+	cassette *Cassette
+	player   *replay.Player
+}
+
+// New creates a Transport for the cassette at the passed path. In ModeReplay
+// the cassette is loaded immediately, in ModeRecord a new cassette is written
+// to the path by Save()
+func New(path string, mode Mode) (*Transport, error) {
+	t := &Transport{
+		path:     path,
+		mode:     mode,
+		next:     http.DefaultTransport,
+		matchers: DefaultMatchers,
+		cassette: &Cassette{},
+	}
+	if mode == ModeReplay {
+		c, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		t.cassette = c
+		t.player = replay.NewPlayer(len(c.Interactions))
+	}
+	return t, nil
+}
+
+// WithTransport sets the http RoundTripper used to perform requests in
+// ModeRecord
+func (t *Transport) WithTransport(next http.RoundTripper) *Transport {
+	t.next = next
+	return t
+}
+
+// WithMatchers replaces the Matchers used to match requests in ModeReplay. A
+// request matches a recorded request when every Matcher agrees
+func (t *Transport) WithMatchers(matchers ...Matcher) *Transport {
+	t.matchers = matchers
+	return t
+}
+
+// WithRedactors adds Redactors that are applied to every Interaction as it's
+// recorded
+func (t *Transport) WithRedactors(redactors ...Redactor) *Transport {
+	t.redactors = append(t.redactors, redactors...)
+	return t
+}
+
+// Save writes the recorded cassette to its path. It does nothing in
+// ModeReplay
+func (t *Transport) Save() error {
+	if t.mode != ModeRecord {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cassette.Save(t.path)
+}
+
+// RoundTrip records or replays the passed http Request depending on the mode
+// of the Transport
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := replay.ReadBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if t.mode == ModeRecord {
+		return t.record(req, body)
+	}
+	return t.replay(req, body)
+}
+
+// record performs the passed http Request and records the Interaction
+func (t *Transport) record(req *http.Request, body []byte) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	res, err := t.next.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	// Credentials are always redacted so cassettes are safe to commit
+	header, u := httpclient.RedactCredentials(req)
+	i := Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    u.String(),
+			Header: header,
+			Body:   body,
+		},
+		Response: Response{
+			StatusCode: res.StatusCode,
+			Header:     res.Header.Clone(),
+			Body:       resBody,
+		},
+	}
+	for _, redact := range t.redactors {
+		redact(&i)
+	}
+
+	t.mu.Lock()
+	t.cassette.Interactions = append(t.cassette.Interactions, i)
+	t.mu.Unlock()
+	return res, nil
+}
+
+// replay answers the passed http Request with the first matching Interaction
+// that hasn't been replayed yet, or the last matching Interaction once they
+// all have
+func (t *Transport) replay(req *http.Request, body []byte) (*http.Response, error) {
+	match := t.player.Next(func(i int) bool {
+		return t.matches(req, body, t.cassette.Interactions[i].Request)
+	})
+	if match < 0 {
+		return nil, t.unmatched(req, body)
+	}
+	recorded := t.cassette.Interactions[match].Response
+	return replay.Response(req, recorded.StatusCode, http.StatusText(recorded.StatusCode), recorded.Header, recorded.Body), nil
+}
+
+// matches reports whether all Matchers match the passed http Request to the
+// recorded Request
+func (t *Transport) matches(req *http.Request, body []byte, recorded Request) bool {
+	for _, m := range t.matchers {
+		if !m(req, body, recorded) {
+			return false
+		}
+	}
+	return true
+}
+
+// unmatched returns a descriptive error for a request that matched no
+// Interaction in the cassette
+func (t *Transport) unmatched(req *http.Request, body []byte) error {
+	_, u := httpclient.RedactCredentials(req)
+	var b strings.Builder
+	fmt.Fprintf(&b, "cassette %s: no interaction matches %s %s", t.path, req.Method, u)
+	if len(body) > 0 {
+		fmt.Fprintf(&b, " with body %q", body)
+	}
+	if len(t.cassette.Interactions) == 0 {
+		b.WriteString(" (the cassette is empty)")
+		return errors.New(b.String())
+	}
+	b.WriteString("; recorded interactions are:")
+	for _, i := range t.cassette.Interactions {
+		fmt.Fprintf(&b, "\n\t%s %s", i.Request.Method, i.Request.URL)
+	}
+	return errors.New(b.String())
+}
@@ -1,15 +1,30 @@
 package httpclient
 
 import (
+	"errors"
 	"net/http"
 	"time"
+
+	"github.com/cenkalti/backoff/v4"
 )
 
 // Client is an http.Client wrapper
 type Client struct {
-	client  *http.Client
-	baseURL string
-	headers []header
+	err           error // Any error configuring the Client, returned by its Requests
+	client        *http.Client
+	baseURL       string
+	headers       []header
+	retryPolicy   RetryPolicy
+	maxRetryAfter time.Duration
+	backoff       backoff.BackOff
+	clock         Clock
+	budget        *RetryBudget
+	middleware    []Middleware
+	credentials   []credential
+	tokens        *tokenCache
+	digest        *digestAuth
+	tls           *tlsSettings
+	urlJoin       URLJoinMode
 }
 
 // header is a struct that contains a key and a value
@@ -26,6 +41,7 @@ func New() *Client {
 // WithClient sets the http client on the Client
 func (c *Client) WithClient(client *http.Client) *Client {
 	c.client = client
+	c.applyTLS()
 	return c
 }
 
@@ -38,12 +54,21 @@ func (c *Client) WithTimeout(timeout time.Duration) *Client {
 // WithTransport sets teh transport on the http Client
 func (c *Client) WithTransport(transport http.RoundTripper) *Client {
 	c.client.Transport = transport
+	c.applyTLS()
 	return c
 }
 
-// WithBaseURL sets the baseURL on the Client
+// WithBaseURL sets the baseURL on the Client that the paths of Requests are
+// joined to. It must be an absolute http or https URL, otherwise Requests
+// created by the Client return an error until a valid one is set
 func (c *Client) WithBaseURL(url string) *Client {
 	c.baseURL = url
+	if errors.Is(c.err, errInvalidBaseURL) {
+		c.err = nil
+	}
+	if url != "" && c.err == nil {
+		c.err = validateBaseURL(url)
+	}
 	return c
 }
 
@@ -53,5 +78,43 @@ func (c *Client) WithHeader(key, value string) *Client {
 	return c
 }
 
+// WithRetryPolicy sets the RetryPolicy used by all Requests created by the
+// Client that don't set their own
+func (c *Client) WithRetryPolicy(policy RetryPolicy) *Client {
+	c.retryPolicy = policy
+	return c
+}
+
+// WithMaxRetryAfter sets the longest Requests created by the Client will wait
+// between retries when a server asks them to with a Retry-After or
+// rate-limit reset header. Requests asked to wait any longer stop retrying
+func (c *Client) WithMaxRetryAfter(max time.Duration) *Client {
+	c.maxRetryAfter = max
+	return c
+}
+
+// WithBackoff sets the backoff.BackOff used by Requests created by the Client
+// to decide how long to wait between retries. Every Request works from its
+// own copy of the built-in strategies and backoff.ExponentialBackOff, other
+// implementations are shared and should be set per Request instead
+func (c *Client) WithBackoff(b backoff.BackOff) *Client {
+	c.backoff = b
+	return c
+}
+
+// WithClock sets the Clock used by Requests created by the Client to time
+// retries
+func (c *Client) WithClock(clock Clock) *Client {
+	c.clock = clock
+	return c
+}
+
+// WithRetryBudget sets a RetryBudget shared by all Requests created by the
+// Client. Once it has been spent Requests stop retrying immediately
+func (c *Client) WithRetryBudget(budget *RetryBudget) *Client {
+	c.budget = budget
+	return c
+}
+
 // Client is a getter that returns a reference to the underlying http Client
 func (c *Client) Client() *http.Client { return c.client }
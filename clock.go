@@ -0,0 +1,36 @@
+package httpclient
+
+import (
+	"context"
+	"time"
+)
+
+// Clock provides the current time and the ability to wait between retries.
+// Replacing the system Clock allows the timing of retries to be tested
+// without actually sleeping
+type Clock interface {
+	Now() time.Time
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// SystemClock is the Clock used unless another is set with WithClock(...)
+var SystemClock Clock = systemClock{}
+
+// systemClock is a Clock backed by the time package
+type systemClock struct{}
+
+// Now returns the current local time
+func (systemClock) Now() time.Time { return time.Now() }
+
+// Sleep pauses for the passed duration or until the context is done,
+// whichever comes first
+func (systemClock) Sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,231 @@
+package httpclient
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// WithCookieJar sets the cookie jar on the http Client, storing cookies set
+// by responses and sending them on later requests. A nil jar sets a new
+// in-memory jar from NewCookieJar()
+func (c *Client) WithCookieJar(jar http.CookieJar) *Client {
+	if jar == nil {
+		jar = NewCookieJar()
+	}
+	c.client.Jar = jar
+	return c
+}
+
+// NewCookieJar creates a new in-memory cookie jar that uses the public suffix
+// list, so servers can't set cookies for domains like co.uk
+func NewCookieJar() http.CookieJar {
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	return jar
+}
+
+// Cookies returns the cookies set by the Responses http Response
+func (r *Response) Cookies() []*http.Cookie { return r.res.Cookies() }
+
+// Cookie returns the named cookie set by the Responses http Response, or nil
+// if it wasn't set
+func (r *Response) Cookie(name string) *http.Cookie {
+	for _, c := range r.res.Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// CookieStore loads and saves the cookies held by a PersistentJar. Cookies
+// are passed with their Domain, Path and absolute Expires set; a Domain with
+// a leading dot is sent to subdomains, one without is sent to that host only,
+// and a zero Expires is a session cookie
+type CookieStore interface {
+	Load() ([]*http.Cookie, error)
+	Save(cookies []*http.Cookie) error
+}
+
+// PersistentJar is a cookie jar, like that from NewCookieJar(), whose cookies
+// are loaded from a CookieStore when created and written back with Save()
+type PersistentJar struct {
+	jar   http.CookieJar
+	store CookieStore
+	now   func() time.Time
+
+	mu      sync.Mutex
+	cookies map[string]*http.Cookie // Keyed by domain, path and name
+}
+
+// NewPersistentJar creates a new PersistentJar holding the cookies loaded
+// from the passed CookieStore
+func NewPersistentJar(store CookieStore) (*PersistentJar, error) {
+	j := &PersistentJar{
+		jar:     NewCookieJar(),
+		store:   store,
+		now:     time.Now,
+		cookies: map[string]*http.Cookie{},
+	}
+	cookies, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("loading cookies: %w", err)
+	}
+	now := j.now()
+	for _, c := range cookies {
+		if !c.Expires.IsZero() && !c.Expires.After(now) {
+			continue
+		}
+		u, replay := replayCookie(c)
+		j.jar.SetCookies(u, []*http.Cookie{replay})
+		j.cookies[cookieKey(c)] = c
+	}
+	return j, nil
+}
+
+// SetCookies stores the cookies set by a response from the passed URL
+func (j *PersistentJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := j.now()
+	for _, c := range cookies {
+		stored, ok := storedCookie(u, c, now)
+		if !ok {
+			continue
+		}
+		if !stored.Expires.IsZero() && !stored.Expires.After(now) {
+			delete(j.cookies, cookieKey(stored))
+			continue
+		}
+		// The jar rejects cookies for other domains or public suffixes, so
+		// only record those it holds
+		if holdsCookie(j.jar, stored) {
+			j.cookies[cookieKey(stored)] = stored
+		}
+	}
+}
+
+// Cookies returns the cookies to send in a request to the passed URL
+func (j *PersistentJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// Save writes the unexpired cookies held by the PersistentJar to its
+// CookieStore
+func (j *PersistentJar) Save() error {
+	j.mu.Lock()
+	now := j.now()
+	cookies := make([]*http.Cookie, 0, len(j.cookies))
+	for key, c := range j.cookies {
+		if !c.Expires.IsZero() && !c.Expires.After(now) {
+			delete(j.cookies, key)
+			continue
+		}
+		cp := *c
+		cookies = append(cookies, &cp)
+	}
+	j.mu.Unlock()
+
+	sort.Slice(cookies, func(a, b int) bool {
+		return cookieKey(cookies[a]) < cookieKey(cookies[b])
+	})
+	if err := j.store.Save(cookies); err != nil {
+		return fmt.Errorf("saving cookies: %w", err)
+	}
+	return nil
+}
+
+// storedCookie returns the passed cookie, set by a response from the passed
+// URL, in the form passed to a CookieStore. It returns false when the cookie
+// is invalid
+func storedCookie(u *url.URL, c *http.Cookie, now time.Time) (*http.Cookie, bool) {
+	if c.Name == "" {
+		return nil, false
+	}
+	host := strings.ToLower(u.Hostname())
+	stored := &http.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Domain:   host,
+		Path:     c.Path,
+		Secure:   c.Secure,
+		HttpOnly: c.HttpOnly,
+		SameSite: c.SameSite,
+	}
+	if d := strings.ToLower(strings.TrimPrefix(c.Domain, ".")); d != "" && net.ParseIP(host) == nil {
+		stored.Domain = "." + d
+	}
+	if stored.Path == "" || !strings.HasPrefix(stored.Path, "/") {
+		stored.Path = defaultCookiePath(u.Path)
+	}
+	switch {
+	case c.MaxAge < 0:
+		stored.Expires = time.Unix(0, 0)
+	case c.MaxAge > 0:
+		stored.Expires = now.Add(time.Duration(c.MaxAge) * time.Second).Truncate(time.Second)
+	case !c.Expires.IsZero():
+		stored.Expires = c.Expires.Truncate(time.Second)
+		if !stored.Expires.After(now) {
+			stored.Expires = time.Unix(0, 0)
+		}
+	}
+	return stored, true
+}
+
+// defaultCookiePath returns the path a cookie without one applies to, as
+// described in RFC 6265 section 5.1.4
+func defaultCookiePath(path string) string {
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return path[:i]
+}
+
+// replayCookie returns a URL and cookie that recreate the passed stored
+// cookie when set on a jar
+func replayCookie(c *http.Cookie) (*url.URL, *http.Cookie) {
+	u := &url.URL{Scheme: "http", Host: strings.TrimPrefix(c.Domain, "."), Path: c.Path}
+	if c.Secure {
+		u.Scheme = "https"
+	}
+	replay := &http.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Path:     c.Path,
+		Expires:  c.Expires,
+		Secure:   c.Secure,
+		HttpOnly: c.HttpOnly,
+		SameSite: c.SameSite,
+	}
+	if strings.HasPrefix(c.Domain, ".") {
+		replay.Domain = c.Domain
+	}
+	return u, replay
+}
+
+// holdsCookie reports whether the passed jar holds the passed stored cookie
+func holdsCookie(jar http.CookieJar, stored *http.Cookie) bool {
+	u, _ := replayCookie(stored)
+	for _, c := range jar.Cookies(u) {
+		if c.Name == stored.Name && c.Value == stored.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// cookieKey returns the key identifying the passed stored cookie
+func cookieKey(c *http.Cookie) string {
+	return c.Domain + ";" + c.Path + ";" + c.Name
+}
@@ -0,0 +1,129 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPersistentJar(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/", MaxAge: 3600, HttpOnly: true})
+			http.SetCookie(w, &http.Cookie{Name: "visit", Value: "1", Path: "/"})
+			http.SetCookie(w, &http.Cookie{Name: "old", Value: "x", Path: "/", MaxAge: 60})
+		case "/logout":
+			http.SetCookie(w, &http.Cookie{Name: "old", Path: "/", MaxAge: -1})
+		}
+		var names []string
+		for _, c := range r.Cookies() {
+			names = append(names, c.Name+"="+c.Value)
+		}
+		sort.Strings(names)
+		w.Write([]byte(strings.Join(names, ";")))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		format CookieFormat
+	}{
+		{"netscape", CookieFormatNetscape},
+		{"json", CookieFormatJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewFileCookieStore(filepath.Join(t.TempDir(), "cookies"), tt.format)
+			jar, err := NewPersistentJar(store)
+			if err != nil {
+				t.Fatalf("NewPersistentJar() error = %v", err)
+			}
+			c := New().WithBaseURL(srv.URL).WithCookieJar(jar)
+			res, err := c.Get("/login").Do()
+			if err != nil {
+				t.Fatalf("Request.Do() error = %v", err)
+			}
+			res.Close()
+			if got := res.Cookie("session"); got == nil || got.Value != "abc" {
+				t.Errorf("Response.Cookie(session) = %v, want abc", got)
+			}
+			if got := len(res.Cookies()); got != 3 {
+				t.Errorf("len(Response.Cookies()) = %d, want 3", got)
+			}
+			if err := c.Get("/logout").Error(); err != nil {
+				t.Fatalf("Request.Error() error = %v", err)
+			}
+			if err := jar.Save(); err != nil {
+				t.Fatalf("PersistentJar.Save() error = %v", err)
+			}
+
+			// A new jar loaded from the same store sends the saved cookies
+			jar, err = NewPersistentJar(store)
+			if err != nil {
+				t.Fatalf("NewPersistentJar() error = %v", err)
+			}
+			got, err := New().WithBaseURL(srv.URL).WithCookieJar(jar).Get("/").String()
+			if err != nil {
+				t.Fatalf("Request.String() error = %v", err)
+			}
+			if want := "session=abc;visit=1"; got != want {
+				t.Errorf("cookies sent = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeNetscapeCookies(t *testing.T) {
+	file := strings.Join([]string{
+		netscapeHeader,
+		"# This file was generated by libcurl",
+		"",
+		".example.com\tTRUE\t/\tTRUE\t1893456000\tid\t42",
+		"#HttpOnly_www.example.com\tFALSE\t/app\tFALSE\t0\tsession\tabc",
+	}, "\n")
+	cookies, err := decodeNetscapeCookies([]byte(file))
+	if err != nil {
+		t.Fatalf("decodeNetscapeCookies() error = %v", err)
+	}
+	want := []*http.Cookie{
+		{Name: "id", Value: "42", Domain: ".example.com", Path: "/", Secure: true, Expires: time.Unix(1893456000, 0)},
+		{Name: "session", Value: "abc", Domain: "www.example.com", Path: "/app", HttpOnly: true},
+	}
+	if len(cookies) != len(want) {
+		t.Fatalf("decodeNetscapeCookies() = %d cookies, want %d", len(cookies), len(want))
+	}
+	for i := range want {
+		if cookies[i].String() != want[i].String() || cookies[i].HttpOnly != want[i].HttpOnly {
+			t.Errorf("cookie %d = %v, want %v", i, cookies[i], want[i])
+		}
+	}
+	if got := string(encodeNetscapeCookies(cookies)); !strings.Contains(got, strings.Join(strings.Split(file, "\n")[3:], "\n")) {
+		t.Errorf("encodeNetscapeCookies() =\n%s", got)
+	}
+}
+
+func TestNewCookieJar_PublicSuffix(t *testing.T) {
+	tests := []struct {
+		domain string
+		want   int
+	}{
+		{"example.co.uk", 1},
+		{"co.uk", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			jar := NewCookieJar()
+			u, _ := url.Parse("https://www.example.co.uk/")
+			jar.SetCookies(u, []*http.Cookie{{Name: "a", Value: "b", Domain: tt.domain}})
+			if got := len(jar.Cookies(u)); got != tt.want {
+				t.Errorf("len(Cookies()) = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
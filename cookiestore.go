@@ -0,0 +1,215 @@
+package httpclient
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CookieFormat is the format a FileCookieStore reads and writes
+type CookieFormat int
+
+const (
+	// CookieFormatNetscape is the Netscape cookies.txt format used by curl,
+	// wget and browser extensions
+	CookieFormatNetscape CookieFormat = iota
+
+	// CookieFormatJSON is a JSON array of cookie objects
+	CookieFormatJSON
+)
+
+// netscapeHeader is the first line of a Netscape cookies.txt file
+const netscapeHeader = "# Netscape HTTP Cookie File"
+
+// httpOnlyPrefix marks HttpOnly cookies in a Netscape cookies.txt file
+const httpOnlyPrefix = "#HttpOnly_"
+
+// FileCookieStore is a CookieStore that keeps cookies in a file
+type FileCookieStore struct {
+	path   string
+	format CookieFormat
+}
+
+// NewFileCookieStore creates a new FileCookieStore that keeps cookies in the
+// file at the passed path, in the passed format. The file is created on the
+// first Save if it doesn't exist
+func NewFileCookieStore(path string, format CookieFormat) *FileCookieStore {
+	return &FileCookieStore{path: path, format: format}
+}
+
+// Load reads the cookies from the file, returning none if it doesn't exist
+func (s *FileCookieStore) Load() ([]*http.Cookie, error) {
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.format == CookieFormatJSON {
+		return decodeJSONCookies(b)
+	}
+	return decodeNetscapeCookies(b)
+}
+
+// Save replaces the cookies in the file, writing it readable only by the
+// current user
+func (s *FileCookieStore) Save(cookies []*http.Cookie) error {
+	var b []byte
+	var err error
+	if s.format == CookieFormatJSON {
+		b, err = encodeJSONCookies(cookies)
+	} else {
+		b = encodeNetscapeCookies(cookies)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a failed write doesn't lose cookies
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path)
+}
+
+// encodeNetscapeCookies encodes the passed cookies in the Netscape
+// cookies.txt format
+func encodeNetscapeCookies(cookies []*http.Cookie) []byte {
+	var b bytes.Buffer
+	b.WriteString(netscapeHeader + "\n\n")
+	for _, c := range cookies {
+		var expires int64
+		if !c.Expires.IsZero() {
+			expires = c.Expires.Unix()
+		}
+		domain := c.Domain
+		if c.HttpOnly {
+			domain = httpOnlyPrefix + domain
+		}
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", domain,
+			netscapeBool(strings.HasPrefix(c.Domain, ".")), c.Path,
+			netscapeBool(c.Secure), expires, c.Name, c.Value)
+	}
+	return b.Bytes()
+}
+
+// decodeNetscapeCookies decodes cookies in the Netscape cookies.txt format
+func decodeNetscapeCookies(b []byte) ([]*http.Cookie, error) {
+	var cookies []*http.Cookie
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := strings.HasPrefix(line, httpOnlyPrefix)
+		line = strings.TrimPrefix(line, httpOnlyPrefix)
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("cookies line %d: expected 7 fields, got %d", n, len(fields))
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cookies line %d: invalid expiry %q", n, fields[4])
+		}
+		c := &http.Cookie{
+			Domain:   strings.TrimPrefix(fields[0], "."),
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+			HttpOnly: httpOnly,
+		}
+		if strings.EqualFold(fields[1], "TRUE") {
+			c.Domain = "." + c.Domain
+		}
+		if expires > 0 {
+			c.Expires = time.Unix(expires, 0)
+		}
+		cookies = append(cookies, c)
+	}
+	return cookies, scanner.Err()
+}
+
+// netscapeBool returns the passed bool as written in a Netscape cookies.txt
+// file
+func netscapeBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// jsonCookie is a cookie as written by CookieFormatJSON
+type jsonCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Domain   string `json:"domain"`
+	Path     string `json:"path"`
+	Expires  int64  `json:"expires,omitempty"` // Unix seconds, omitted for session cookies
+	Secure   bool   `json:"secure,omitempty"`
+	HttpOnly bool   `json:"http_only,omitempty"`
+}
+
+// encodeJSONCookies encodes the passed cookies as a JSON array
+func encodeJSONCookies(cookies []*http.Cookie) ([]byte, error) {
+	out := make([]jsonCookie, 0, len(cookies))
+	for _, c := range cookies {
+		jc := jsonCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+		}
+		if !c.Expires.IsZero() {
+			jc.Expires = c.Expires.Unix()
+		}
+		out = append(out, jc)
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// decodeJSONCookies decodes cookies from a JSON array
+func decodeJSONCookies(b []byte) ([]*http.Cookie, error) {
+	var in []jsonCookie
+	if err := json.Unmarshal(b, &in); err != nil {
+		return nil, err
+	}
+	cookies := make([]*http.Cookie, 0, len(in))
+	for _, jc := range in {
+		c := &http.Cookie{
+			Name:     jc.Name,
+			Value:    jc.Value,
+			Domain:   jc.Domain,
+			Path:     jc.Path,
+			Secure:   jc.Secure,
+			HttpOnly: jc.HttpOnly,
+		}
+		if jc.Expires > 0 {
+			c.Expires = time.Unix(jc.Expires, 0)
+		}
+		cookies = append(cookies, c)
+	}
+	return cookies, nil
+}
@@ -7,6 +7,7 @@ import (
 
 // Postf takes a format and a variadic of arguments and returns a prepopulated
 // Post request
+// NOTE: Arguments aren't escaped, so use WithPathParam(...) for user input
 func (c *Client) Postf(format string, a ...interface{}) *Request {
 	return c.Post(fmt.Sprintf(format, a...))
 }
@@ -18,6 +19,7 @@ func (c *Client) Post(path string) *Request {
 
 // Putf takes a format and a variadic of arguments and returns a prepopulated
 // Put request
+// NOTE: Arguments aren't escaped, so use WithPathParam(...) for user input
 func (c *Client) Putf(format string, a ...interface{}) *Request {
 	return c.Put(fmt.Sprintf(format, a...))
 }
@@ -29,6 +31,7 @@ func (c *Client) Put(path string) *Request {
 
 // Patchf takes a format and a variadic of arguments and returns a prepopulated
 // Patch request
+// NOTE: Arguments aren't escaped, so use WithPathParam(...) for user input
 func (c *Client) Patchf(format string, a ...interface{}) *Request {
 	return c.Patch(fmt.Sprintf(format, a...))
 }
@@ -40,6 +43,7 @@ func (c *Client) Patch(path string) *Request {
 
 // Headf takes a format and a variadic of arguments and returns a prepopulated
 // Head request
+// NOTE: Arguments aren't escaped, so use WithPathParam(...) for user input
 func (c *Client) Headf(format string, a ...interface{}) *Request {
 	return c.Head(fmt.Sprintf(format, a...))
 }
@@ -51,6 +55,7 @@ func (c *Client) Head(path string) *Request {
 
 // Getf takes a format and a variadic of arguments and returns a prepopulated
 // Get request
+// NOTE: Arguments aren't escaped, so use WithPathParam(...) for user input
 func (c *Client) Getf(format string, a ...interface{}) *Request {
 	return c.Get(fmt.Sprintf(format, a...))
 }
@@ -62,6 +67,7 @@ func (c *Client) Get(path string) *Request {
 
 // Deletef takes a format and a variadic of arguments and returns a prepopulated
 // Delete request
+// NOTE: Arguments aren't escaped, so use WithPathParam(...) for user input
 func (c *Client) Deletef(format string, a ...interface{}) *Request {
 	return c.Delete(fmt.Sprintf(format, a...))
 }
@@ -74,11 +80,22 @@ func (c *Client) Delete(path string) *Request {
 // Request creates a new Request copying configuration from the base Client
 func (c *Client) Request(method, path string) *Request {
 	r := &Request{
-		client:  c.client,
-		method:  method,
-		baseURL: c.baseURL,
-		path:    path,
-		headers: []header{},
+		err:           c.err,
+		client:        c.client,
+		method:        method,
+		baseURL:       c.baseURL,
+		urlJoin:       c.urlJoin,
+		path:          path,
+		headers:       []header{},
+		retryPolicy:   c.retryPolicy,
+		maxRetryAfter: c.maxRetryAfter,
+		backoff:       c.backoff,
+		clock:         c.clock,
+		budget:        c.budget,
+		middleware:    append([]Middleware(nil), c.middleware...),
+		credentials:   c.credentials,
+		tokens:        c.tokens,
+		digest:        c.digest,
 	}
 	for _, h := range c.headers {
 		r.headers = append(r.headers, header{key: h.key, value: h.value})
@@ -0,0 +1,119 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// errStreamCurl is returned when rendering a Request whose body is a stream
+var errStreamCurl = errors.New("can't render a streamed body as a cURL command")
+
+// Curl renders the Request, with all Client and Request headers and its body,
+// as a cURL command that can be run in any POSIX shell. Credentials set with
+// WithBasicAuth(...), WithBearerToken(...) or WithAPIKey(...) are always
+// redacted
+func (r *Request) Curl() (string, error) { return r.curl(nil) }
+
+// RedactedCurl is identical to Curl(...) but replaces the values of all
+// SensitiveHeaders and the passed headers so the command can be shared
+func (r *Request) RedactedCurl(headers ...string) (string, error) {
+	return r.curl(append([]string{}, headers...))
+}
+
+// curl renders the Request as a cURL command, redacting the passed headers
+// when not nil
+func (r *Request) curl(redact []string) (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+	if buffered, err := r.bufferBody(); err != nil {
+		return "", err
+	} else if !buffered {
+		return "", errStreamCurl
+	}
+	req, err := r.toHTTPRequest()
+	if err != nil {
+		return "", err
+	}
+
+	// Read the body, leaving the Request able to be performed afterwards
+	var body []byte
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		defer rc.Close()
+		if body, err = io.ReadAll(rc); err != nil {
+			return "", err
+		}
+	} else if req.Body != nil && req.Body != http.NoBody {
+		return "", errStreamCurl
+	}
+
+	header, u := redactCredentials(req.Header, req.URL, r.credentials)
+	if redact != nil {
+		header = RedactHeader(header, redact...)
+	}
+
+	var b strings.Builder
+	b.WriteString("curl")
+	switch {
+	case req.Method == http.MethodHead:
+		b.WriteString(" --head")
+	case req.Method != http.MethodGet || len(body) > 0:
+		b.WriteString(" -X " + shellQuote(req.Method))
+	}
+	b.WriteString(" " + shellQuote(u.String()))
+
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range header[k] {
+			b.WriteString(" -H " + shellQuote(k+": "+v))
+		}
+	}
+
+	if len(body) == 0 {
+		return b.String(), nil
+	}
+	if isPrintable(body) {
+		b.WriteString(" --data-raw " + shellQuote(string(body)))
+		return b.String(), nil
+	}
+
+	// Pipe binary bodies in through printf, which understands octal escapes
+	var esc strings.Builder
+	for _, c := range body {
+		fmt.Fprintf(&esc, "\\%03o", c)
+	}
+	return "printf '" + esc.String() + "' | " + b.String() + " --data-binary @-", nil
+}
+
+// shellQuote quotes the passed string so it's taken literally by a POSIX
+// shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// isPrintable reports whether the passed body can be safely written in a
+// shell command as text
+func isPrintable(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if r < ' ' && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
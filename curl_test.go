@@ -0,0 +1,139 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"testing"
+)
+
+func TestRequest_Curl(t *testing.T) {
+	c := New().WithBaseURL("https://example.com").
+		WithHeader("Authorization", "Bearer token")
+	tests := []struct {
+		name    string
+		request *Request
+		redact  bool
+		want    string
+	}{
+		{
+			name:    "get",
+			request: c.Get("/users"),
+			want:    `curl 'https://example.com/users' -H 'Authorization: Bearer token'`,
+		},
+		{
+			name:    "head",
+			request: c.Head("/users"),
+			want:    `curl --head 'https://example.com/users' -H 'Authorization: Bearer token'`,
+		},
+		{
+			name:    "delete without body",
+			request: c.Delete("/users/1"),
+			want:    `curl -X 'DELETE' 'https://example.com/users/1' -H 'Authorization: Bearer token'`,
+		},
+		{
+			name:    "json with quotes",
+			request: c.Post("/users").WithJSON(map[string]string{"name": "o'brien"}),
+			want: `curl -X 'POST' 'https://example.com/users' -H 'Authorization: Bearer token' ` +
+				`-H 'Content-Type: application/json' --data-raw '{"name":"o'\''brien"}` + "\n'",
+		},
+		{
+			name:    "form",
+			request: c.Put("/users/1").WithForm(url.Values{"a": {"b c"}}),
+			want: `curl -X 'PUT' 'https://example.com/users/1' -H 'Authorization: Bearer token' ` +
+				`-H 'Content-Type: application/x-www-form-urlencoded' --data-raw 'a=b+c'`,
+		},
+		{
+			name:    "binary",
+			request: c.Post("/upload").WithBytes([]byte{0, 'a', 255}),
+			want:    `printf '\000\141\377' | curl -X 'POST' 'https://example.com/upload' -H 'Authorization: Bearer token' --data-binary @-`,
+		},
+		{
+			name:    "redacted",
+			request: c.Get("/users").WithHeader("X-Api-Key", "secret"),
+			redact:  true,
+			want:    `curl 'https://example.com/users' -H 'Authorization: [REDACTED]' -H 'X-Api-Key: [REDACTED]'`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			var err error
+			if tt.redact {
+				got, err = tt.request.RedactedCurl("X-Api-Key")
+			} else {
+				got, err = tt.request.Curl()
+			}
+			if err != nil {
+				t.Fatalf("Request.Curl() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Request.Curl() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequest_Curl_Runs(t *testing.T) {
+	if _, err := exec.LookPath("curl"); err != nil {
+		t.Skip("curl is not installed")
+	}
+	received := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		received <- b
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		body []byte
+	}{
+		{name: "text", body: []byte("it's a \"quoted\" $HOME `body`\n")},
+		{name: "binary", body: []byte{0, 1, 2, '\'', 255}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New().WithBaseURL(srv.URL).Post("/").WithBytes(tt.body)
+			cmd, err := r.Curl()
+			if err != nil {
+				t.Fatalf("Request.Curl() error = %v", err)
+			}
+			if out, err := exec.Command("sh", "-c", cmd+" -s").CombinedOutput(); err != nil {
+				t.Fatalf("running %s: %v: %s", cmd, err, out)
+			}
+			if got := <-received; string(got) != string(tt.body) {
+				t.Errorf("server received %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestRequest_Curl_Stream(t *testing.T) {
+	_, err := New().Post("https://example.com").WithStream(readWriter("x")).Curl()
+	if err != errStreamCurl {
+		t.Errorf("Request.Curl() error = %v, want %v", err, errStreamCurl)
+	}
+}
+
+func TestRequest_Curl_OverLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	}))
+	defer srv.Close()
+
+	// The over-limit body can't be rendered but must still be sent whole
+	r := New().WithBaseURL(srv.URL).Post("/").WithBody(readWriter("0123456789")).WithBodyBufferLimit(3)
+	if _, err := r.Curl(); err != errStreamCurl {
+		t.Errorf("Request.Curl() error = %v, want %v", err, errStreamCurl)
+	}
+	got, err := r.String()
+	if err != nil {
+		t.Fatalf("Request.String() error = %v", err)
+	}
+	if got != "0123456789" {
+		t.Errorf("server received %q, want %q", got, "0123456789")
+	}
+}
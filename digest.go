@@ -0,0 +1,242 @@
+package httpclient
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// WithDigestAuth sets the username and password used to authenticate every
+// Request created by the Client using HTTP Digest authentication (RFC 7616).
+// Requests answered with a Digest challenge are retried with a response to
+// it, and the challenge is cached per host so later requests answer it
+// straight away
+func (c *Client) WithDigestAuth(username, password string) *Client {
+	c.digest = newDigestAuth(username, password)
+	return c
+}
+
+// WithDigestAuth sets the username and password used to authenticate the
+// Request using HTTP Digest authentication (RFC 7616), replacing any set on
+// the Client
+func (r *Request) WithDigestAuth(username, password string) *Request {
+	r.digest = newDigestAuth(username, password)
+	return r
+}
+
+// digestAuth answers Digest challenges, caching the latest challenge from
+// each host
+type digestAuth struct {
+	username, password string
+
+	mu         sync.Mutex
+	challenges map[string]*digestChallenge
+}
+
+// digestChallenge is a Digest challenge from a WWW-Authenticate header
+type digestChallenge struct {
+	realm, nonce, opaque string
+	algorithm            string
+	qop                  bool // Whether qop=auth is used
+	nc                   int  // The number of times the nonce has been used
+}
+
+// cnonce returns a new random client nonce, and is replaced in tests
+var cnonce = func() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// newDigestAuth creates a new digestAuth for the passed credentials
+func newDigestAuth(username, password string) *digestAuth {
+	return &digestAuth{
+		username:   username,
+		password:   password,
+		challenges: map[string]*digestChallenge{},
+	}
+}
+
+// digestAuthenticate wraps the passed Handler, answering Digest challenges
+// with the Requests digest credentials
+func (r *Request) digestAuthenticate(next Handler) Handler {
+	if r.digest == nil {
+		return next
+	}
+	return func(req *http.Request) (*Response, error) {
+		// Answer the last challenge from the host straight away if there is one
+		host := req.URL.Host
+		sent := req
+		if auth, ok := r.digest.authorization(host, req); ok {
+			sent = withCredential(req, credential{APIKeyInHeader, "Authorization", auth})
+		}
+		res, err := next(sent)
+		if err != nil || res.res.StatusCode != http.StatusUnauthorized || !replayable(req) {
+			return res, err
+		}
+
+		// Retry once answering the new challenge
+		ch := parseDigestChallenge(res.res.Header.Values("WWW-Authenticate"))
+		if ch == nil {
+			return res, nil
+		}
+		r.digest.setChallenge(host, ch)
+		auth, _ := r.digest.authorization(host, req)
+		if req, err = rewind(req); err != nil {
+			discard(res.res)
+			return nil, err
+		}
+		discard(res.res)
+		return next(withCredential(req, credential{APIKeyInHeader, "Authorization", auth}))
+	}
+}
+
+// setChallenge caches the passed challenge for the passed host
+func (d *digestAuth) setChallenge(host string, ch *digestChallenge) {
+	d.mu.Lock()
+	d.challenges[host] = ch
+	d.mu.Unlock()
+}
+
+// authorization returns the Authorization header answering the cached
+// challenge for the passed host, counting another use of its nonce
+func (d *digestAuth) authorization(host string, req *http.Request) (string, bool) {
+	d.mu.Lock()
+	ch := d.challenges[host]
+	if ch == nil {
+		d.mu.Unlock()
+		return "", false
+	}
+	ch.nc++
+	nc := ch.nc
+	d.mu.Unlock()
+	return d.response(ch, req.Method, req.URL.RequestURI(), nc, cnonce()), true
+}
+
+// response computes the Authorization header answering the passed challenge
+func (d *digestAuth) response(ch *digestChallenge, method, uri string, nc int, cnonce string) string {
+	newHash := md5.New
+	if strings.HasPrefix(strings.ToUpper(ch.algorithm), "SHA-256") {
+		newHash = sha256.New
+	}
+	h := func(s string) string {
+		hh := newHash()
+		hh.Write([]byte(s))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+
+	ncValue := fmt.Sprintf("%08x", nc)
+	ha1 := h(d.username + ":" + ch.realm + ":" + d.password)
+	if strings.HasSuffix(strings.ToLower(ch.algorithm), "-sess") {
+		ha1 = h(ha1 + ":" + ch.nonce + ":" + cnonce)
+	}
+	ha2 := h(method + ":" + uri)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `Digest username=%s, realm=%s, uri=%s`, quote(d.username), quote(ch.realm), quote(uri))
+	if ch.algorithm != "" {
+		fmt.Fprintf(&b, ", algorithm=%s", ch.algorithm)
+	}
+	fmt.Fprintf(&b, ", nonce=%s", quote(ch.nonce))
+	if ch.qop {
+		resp := h(ha1 + ":" + ch.nonce + ":" + ncValue + ":" + cnonce + ":auth:" + ha2)
+		fmt.Fprintf(&b, `, nc=%s, cnonce=%s, qop=auth, response=%s`, ncValue, quote(cnonce), quote(resp))
+	} else {
+		fmt.Fprintf(&b, ", response=%s", quote(h(ha1+":"+ch.nonce+":"+ha2)))
+	}
+	if ch.opaque != "" {
+		fmt.Fprintf(&b, ", opaque=%s", quote(ch.opaque))
+	}
+	return b.String()
+}
+
+// digestHashes are the algorithms supported in order of preference
+var digestHashes = map[string]int{
+	"SHA-256-SESS": 4,
+	"SHA-256":      3,
+	"MD5-SESS":     2,
+	"MD5":          1,
+	"":             1,
+}
+
+// parseDigestChallenge returns the strongest supported Digest challenge in
+// the passed WWW-Authenticate header values, or nil if there isn't one
+func parseDigestChallenge(headers []string) *digestChallenge {
+	var best *digestChallenge
+	for _, h := range headers {
+		scheme, rest, _ := strings.Cut(strings.TrimSpace(h), " ")
+		if !strings.EqualFold(scheme, "Digest") {
+			continue
+		}
+		params := parseAuthParams(rest)
+		ch := &digestChallenge{
+			realm:     params["realm"],
+			nonce:     params["nonce"],
+			opaque:    params["opaque"],
+			algorithm: params["algorithm"],
+		}
+		if ch.nonce == "" || digestHashes[strings.ToUpper(ch.algorithm)] == 0 {
+			continue
+		}
+		if qop, ok := params["qop"]; ok {
+			for _, q := range strings.Split(qop, ",") {
+				if strings.TrimSpace(q) == "auth" {
+					ch.qop = true
+				}
+			}
+			// Only auth-int is offered, which would mean hashing the body
+			if !ch.qop {
+				continue
+			}
+		}
+		if best == nil || digestHashes[strings.ToUpper(ch.algorithm)] > digestHashes[strings.ToUpper(best.algorithm)] {
+			best = ch
+		}
+	}
+	return best
+}
+
+// parseAuthParams parses the comma separated key=value parameters of an
+// authentication challenge, where values may be quoted strings
+func parseAuthParams(s string) map[string]string {
+	params := map[string]string{}
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			return params
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		rest = strings.TrimLeft(rest, " \t")
+
+		var value strings.Builder
+		if strings.HasPrefix(rest, `"`) {
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				value.WriteByte(rest[i])
+			}
+			s = rest[min(i+1, len(rest)):]
+		} else {
+			end := strings.IndexByte(rest, ',')
+			if end < 0 {
+				end = len(rest)
+			}
+			value.WriteString(strings.TrimSpace(rest[:end]))
+			s = rest[end:]
+		}
+		params[key] = value.String()
+	}
+}
+
+// quote returns the passed string as a quoted string
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,149 @@
+package httpclient
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDigestAuth_Response(t *testing.T) {
+	// The examples from RFC 7616 section 3.9.1
+	tests := []struct {
+		algorithm string
+		want      string
+	}{
+		{"MD5", "8ca523f5e9506fed4657c9700eebdbec"},
+		{"SHA-256", "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			d := newDigestAuth("Mufasa", "Circle of Life")
+			ch := &digestChallenge{
+				realm:     "http-auth@example.org",
+				nonce:     "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+				opaque:    "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS",
+				algorithm: tt.algorithm,
+				qop:       true,
+			}
+			got := d.response(ch, http.MethodGet, "/dir/index.html", 1, "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ")
+			want := `Digest username="Mufasa", realm="http-auth@example.org", uri="/dir/index.html", ` +
+				`algorithm=` + tt.algorithm + `, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", ` +
+				`nc=00000001, cnonce="f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ", qop=auth, ` +
+				`response="` + tt.want + `", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`
+			if got != want {
+				t.Errorf("response() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestParseDigestChallenge(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+		want    *digestChallenge
+	}{
+		{
+			name: "strongest algorithm",
+			headers: []string{
+				`Digest realm="r", qop="auth, auth-int", algorithm=MD5, nonce="a"`,
+				`Digest realm="r", qop="auth, auth-int", algorithm=SHA-256, nonce="b", opaque="o"`,
+				`Basic realm="r"`,
+			},
+			want: &digestChallenge{realm: "r", nonce: "b", opaque: "o", algorithm: "SHA-256", qop: true},
+		},
+		{
+			name:    "legacy without qop",
+			headers: []string{`Digest realm="r \"quoted\"", nonce="a"`},
+			want:    &digestChallenge{realm: `r "quoted"`, nonce: "a"},
+		},
+		{
+			name:    "only auth-int",
+			headers: []string{`Digest realm="r", qop="auth-int", nonce="a"`},
+		},
+		{
+			name:    "unsupported algorithm",
+			headers: []string{`Digest realm="r", qop="auth", algorithm=SHA-512-256, nonce="a"`},
+		},
+		{
+			name:    "not digest",
+			headers: []string{`Bearer realm="r"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseDigestChallenge(tt.headers)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("parseDigestChallenge() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDigestAuth(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		newHash   func() hash.Hash
+	}{
+		{"MD5", md5.New},
+		{"MD5-sess", md5.New},
+		{"SHA-256", sha256.New},
+		{"SHA-256-sess", sha256.New},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			var requests int32
+			var ncs []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				auth := r.Header.Get("Authorization")
+				if !strings.HasPrefix(auth, "Digest ") {
+					w.Header().Set("WWW-Authenticate", `Digest realm="test", qop="auth", algorithm=`+tt.algorithm+`, nonce="n0nce"`)
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				p := parseAuthParams(strings.TrimPrefix(auth, "Digest "))
+				h := func(s string) string {
+					hh := tt.newHash()
+					hh.Write([]byte(s))
+					return hex.EncodeToString(hh.Sum(nil))
+				}
+				ha1 := h("user:test:pass")
+				if strings.HasSuffix(tt.algorithm, "-sess") {
+					ha1 = h(ha1 + ":n0nce:" + p["cnonce"])
+				}
+				want := h(ha1 + ":n0nce:" + p["nc"] + ":" + p["cnonce"] + ":auth:" + h(r.Method+":"+r.URL.RequestURI()))
+				if p["response"] != want || p["uri"] != r.URL.RequestURI() {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				ncs = append(ncs, p["nc"])
+				w.Write([]byte("ok"))
+			}))
+			defer srv.Close()
+
+			c := New().WithBaseURL(srv.URL).WithDigestAuth("user", "pass")
+			for _, path := range []string{"/a?x=1", "/b"} {
+				got, err := c.Post(path).WithString("body").WithExpectedStatus(http.StatusOK).String()
+				if err != nil {
+					t.Fatalf("Request.String() error = %v", err)
+				}
+				if got != "ok" {
+					t.Errorf("Request.String() = %q, want ok", got)
+				}
+			}
+			if requests != 3 {
+				t.Errorf("server received %d requests, want 3", requests)
+			}
+			if strings.Join(ncs, ",") != "00000001,00000002" {
+				t.Errorf("nonce counts = %v, want 00000001,00000002", ncs)
+			}
+		})
+	}
+}
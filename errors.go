@@ -0,0 +1,77 @@
+package httpclient
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// MaxErrorBodySize is the maximum number of bytes of a response body kept on
+// a StatusError
+var MaxErrorBodySize = 4 << 10 // 4 KiB
+
+// StatusError is returned when a Request doesn't receive the status it
+// expected. It can be retrieved from any error returned by a Request using
+// errors.As
+type StatusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte // At most MaxErrorBodySize bytes of the response body
+	Attempts   int
+	Elapsed    time.Duration
+}
+
+// newStatusError creates a StatusError from the passed http Response, reading
+// (and closing) its body
+func newStatusError(res *http.Response, attempts int, elapsed time.Duration) *StatusError {
+	e := &StatusError{
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Header:     res.Header,
+		Attempts:   attempts,
+		Elapsed:    elapsed,
+	}
+	if res.Request != nil {
+		e.Method = res.Request.Method
+		_, u := RedactRequest(res.Request)
+		e.URL = u.String()
+	}
+	e.Body, _ = io.ReadAll(io.LimitReader(res.Body, int64(MaxErrorBodySize)))
+	discard(res)
+	return e
+}
+
+// Error returns a description of the unexpected status
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("Unexpected status received : %s (%s %s)", e.Status, e.Method, e.URL)
+}
+
+// RetryError is returned when a Request that was attempted more than once
+// still failed. It wraps the error from the last attempt, so a StatusError
+// can still be retrieved using errors.As
+type RetryError struct {
+	Attempts        int
+	Elapsed         time.Duration
+	BudgetExhausted bool  // Whether retrying stopped as the budget was spent
+	Err             error // The error from the last attempt
+}
+
+// Error returns a description of the failed retries
+func (e *RetryError) Error() string {
+	if e.BudgetExhausted {
+		return fmt.Sprintf("%v after %v attempts : %v", ErrRetryBudgetExhausted, e.Attempts, e.Err)
+	}
+	return fmt.Sprintf("request failed after %v attempts : %v", e.Attempts, e.Err)
+}
+
+// Unwrap returns the error from the last attempt
+func (e *RetryError) Unwrap() error { return e.Err }
+
+// Is reports whether the RetryError matches ErrRetryBudgetExhausted
+func (e *RetryError) Is(target error) bool {
+	return target == ErrRetryBudgetExhausted && e.BudgetExhausted
+}
@@ -0,0 +1,92 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "abc")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(strings.Repeat("x", MaxErrorBodySize+10)))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name         string
+		retry        int
+		wantAttempts int
+		wantRetry    bool
+	}{
+		{name: "single attempt", retry: 0, wantAttempts: 1},
+		{name: "retried", retry: 3, wantAttempts: 3, wantRetry: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New().WithBaseURL(srv.URL).
+				WithClock(&fakeClock{}).
+				Get("/missing").
+				WithExpectedStatus(http.StatusOK).
+				WithRetry(tt.retry).
+				Error()
+
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) {
+				t.Fatalf("Request.Error() = %v, want a *StatusError", err)
+			}
+			if statusErr.StatusCode != http.StatusNotFound {
+				t.Errorf("StatusError.StatusCode = %v, want %v", statusErr.StatusCode, http.StatusNotFound)
+			}
+			if statusErr.Method != http.MethodGet || statusErr.URL != srv.URL+"/missing" {
+				t.Errorf("StatusError request = %v %v, want GET %v", statusErr.Method, statusErr.URL, srv.URL+"/missing")
+			}
+			if got := statusErr.Header.Get("X-Request-Id"); got != "abc" {
+				t.Errorf("StatusError.Header X-Request-Id = %q, want %q", got, "abc")
+			}
+			if len(statusErr.Body) != MaxErrorBodySize {
+				t.Errorf("len(StatusError.Body) = %v, want %v", len(statusErr.Body), MaxErrorBodySize)
+			}
+			if statusErr.Attempts != tt.wantAttempts {
+				t.Errorf("StatusError.Attempts = %v, want %v", statusErr.Attempts, tt.wantAttempts)
+			}
+
+			var retryErr *RetryError
+			if errors.As(err, &retryErr) != tt.wantRetry {
+				t.Fatalf("Request.Error() = %v, want a *RetryError %v", err, tt.wantRetry)
+			}
+			if tt.wantRetry && retryErr.Attempts != tt.wantAttempts {
+				t.Errorf("RetryError.Attempts = %v, want %v", retryErr.Attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryError_BudgetExhausted(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	_, err := New().WithBaseURL(srv.URL).
+		WithRetryBudget(NewRetryBudget(0, 0, 0)).
+		WithRetryPolicy(RetryOnServerError).
+		Get("/").
+		WithRetry(3).
+		Do()
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || !retryErr.BudgetExhausted {
+		t.Fatalf("Request.Do() = %v, want an exhausted *RetryError", err)
+	}
+	if !errors.Is(err, ErrRetryBudgetExhausted) {
+		t.Errorf("errors.Is(%v, ErrRetryBudgetExhausted) = false", err)
+	}
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("Request.Do() = %v, want a wrapped 502 *StatusError", err)
+	}
+}
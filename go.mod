@@ -1,8 +1,9 @@
 module github.com/s32x/httpclient
 
-go 1.17
+go 1.21
 
 require (
 	github.com/cenkalti/backoff/v4 v4.1.2
+	golang.org/x/net v0.21.0
 	h12.io/socks v1.0.3
 )
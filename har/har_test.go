@@ -0,0 +1,178 @@
+package har
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/s32x/httpclient"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users":
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == http.MethodPost {
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"id":2}`))
+				return
+			}
+			w.Write([]byte(`[{"id":1}]`))
+		case "/avatar":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte{0x89, 'P', 'N', 'G', 0xff})
+		}
+	}))
+	defer srv.Close()
+
+	type result struct {
+		status int
+		body   string
+	}
+	run := func(c *httpclient.Client) []result {
+		var out []result
+		for _, r := range []*httpclient.Request{
+			c.Get("/users?page=1"),
+			c.Post("/users").WithJSON(map[string]string{"name": "gopher"}),
+			c.Get("/avatar"),
+		} {
+			res, err := r.Do()
+			if err != nil {
+				t.Fatalf("Request.Do() error = %v", err)
+			}
+			body, _ := res.String()
+			res.Close()
+			out = append(out, result{res.StatusCode(), body})
+		}
+		return out
+	}
+
+	// Record the traffic against the real server
+	rec := NewRecorder().WithRedaction()
+	recorded := run(httpclient.New().
+		WithBaseURL(srv.URL).
+		WithHeader("Authorization", "Bearer secret").
+		Use(rec.Middleware()))
+
+	path := filepath.Join(t.TempDir(), "traffic.har")
+	if err := rec.Save(path); err != nil {
+		t.Fatalf("Recorder.Save() error = %v", err)
+	}
+	h, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(h.Log.Entries) != 3 || h.Log.Version != "1.2" {
+		t.Fatalf("Load() = %+v, want 3 HAR 1.2 entries", h.Log)
+	}
+	var buf bytes.Buffer
+	h.Write(&buf)
+	if strings.Contains(buf.String(), "Bearer secret") {
+		t.Errorf("HAR contains a redacted header:\n%s", buf.String())
+	}
+	if e := h.Log.Entries[1]; e.Request.PostData == nil || e.Request.PostData.Text != "{\"name\":\"gopher\"}\n" {
+		t.Errorf("Entry.Request.PostData = %+v, want the JSON body", e.Request.PostData)
+	}
+	if e := h.Log.Entries[2]; e.Response.Content.Encoding != "base64" {
+		t.Errorf("Entry.Response.Content.Encoding = %q, want base64", e.Response.Content.Encoding)
+	}
+	if e := h.Log.Entries[0]; e.Timings.Wait <= 0 || e.ServerIPAddress != "127.0.0.1" {
+		t.Errorf("Entry = %+v, want timings and a server address", e)
+	}
+
+	// Replay the traffic without the server
+	srv.Close()
+	replayed := run(httpclient.New().
+		WithBaseURL(srv.URL).
+		WithTransport(NewTransport(h)))
+	for i := range recorded {
+		if recorded[i] != replayed[i] {
+			t.Errorf("replayed %+v, want %+v", replayed[i], recorded[i])
+		}
+	}
+
+	// Requests that weren't recorded fail
+	_, err = httpclient.New().WithBaseURL(srv.URL).
+		WithTransport(NewTransport(h)).
+		Get("/unknown").
+		Do()
+	if err == nil || !strings.Contains(err.Error(), "no entry recorded") {
+		t.Errorf("Request.Do() error = %v, want an unmatched entry error", err)
+	}
+}
+
+func TestRecorder_CredentialsRedacted(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	// Credentials are redacted even without WithRedaction(...)
+	rec := NewRecorder()
+	err := httpclient.New().
+		WithBaseURL(srv.URL).
+		WithBearerToken("bearer-secret").
+		WithAPIKey("key", "query-secret", httpclient.APIKeyInQuery).
+		WithAPIKey("sid", "cookie-secret", httpclient.APIKeyInCookie).
+		WithHeader("X-Trace", "visible").
+		Use(rec.Middleware()).
+		Get("/users").
+		Error()
+	if err != nil {
+		t.Fatalf("Request.Error() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	rec.HAR().Write(&buf)
+	for _, secret := range []string{"bearer-secret", "query-secret", "cookie-secret"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("HAR contains %s:\n%s", secret, buf.String())
+		}
+	}
+	if !strings.Contains(buf.String(), "visible") {
+		t.Errorf("HAR is missing an unredacted header:\n%s", buf.String())
+	}
+}
+
+func TestRecordAndReplay_QueryAPIKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Query().Get("k")))
+	}))
+	rec := NewRecorder()
+	recorded, err := httpclient.New().
+		WithBaseURL(srv.URL).
+		WithAPIKey("k", "query-secret", httpclient.APIKeyInQuery).
+		Use(rec.Middleware()).
+		Get("/users").
+		String()
+	if err != nil {
+		t.Fatalf("Request.String() error = %v", err)
+	}
+	srv.Close()
+
+	// The entry was recorded with the API key redacted but still matches
+	replayed, err := httpclient.New().
+		WithBaseURL(srv.URL).
+		WithAPIKey("k", "query-secret", httpclient.APIKeyInQuery).
+		WithTransport(NewTransport(rec.HAR())).
+		Get("/users").
+		String()
+	if err != nil {
+		t.Fatalf("Request.String() error = %v", err)
+	}
+	if replayed != recorded {
+		t.Errorf("replayed %q, want %q", replayed, recorded)
+	}
+
+	_, err = httpclient.New().
+		WithBaseURL(srv.URL).
+		WithAPIKey("k", "query-secret", httpclient.APIKeyInQuery).
+		WithTransport(NewTransport(rec.HAR())).
+		Get("/unknown").
+		Do()
+	if err == nil || strings.Contains(err.Error(), "query-secret") {
+		t.Errorf("Request.Do() error = %v, want an unmatched entry error without the API key", err)
+	}
+}
@@ -0,0 +1,220 @@
+package har
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/s32x/httpclient"
+)
+
+// Recorder records every attempt of every Request performed by the Clients it
+// is attached to
+type Recorder struct {
+	mu      sync.Mutex
+	entries []Entry
+	redact  []string
+}
+
+// NewRecorder creates a new, empty, Recorder. Attach it to a Client using
+// client.Use(recorder.Middleware())
+func NewRecorder() *Recorder { return &Recorder{} }
+
+// WithRedaction redacts the values of all httpclient.SensitiveHeaders and the
+// passed headers from the recorded entries, so they can be safely shared.
+// Credentials set with the Clients auth helpers are always redacted
+func (r *Recorder) WithRedaction(headers ...string) *Recorder {
+	r.redact = append([]string{}, headers...)
+	return r
+}
+
+// Middleware returns the httpclient Middleware that records each attempt.
+// Response bodies are read in full so they can be recorded
+func (r *Recorder) Middleware() httpclient.Middleware {
+	return func(next httpclient.Handler) httpclient.Handler {
+		return func(req *http.Request) (*httpclient.Response, error) {
+			started := time.Now()
+			res, err := next(req)
+			if err != nil {
+				return nil, err
+			}
+
+			// Read and replace the body so it can still be read by the caller
+			hr := res.Response()
+			receiveStart := time.Now()
+			body, err := io.ReadAll(hr.Body)
+			hr.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			hr.Body = io.NopCloser(bytes.NewReader(body))
+			receive := time.Since(receiveStart)
+
+			entry := r.entry(req, hr, body, res.Timing(), started, receive)
+			r.mu.Lock()
+			r.entries = append(r.entries, entry)
+			r.mu.Unlock()
+			return res, nil
+		}
+	}
+}
+
+// HAR returns an HTTP Archive of all the entries recorded so far
+func (r *Recorder) HAR() *HAR {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &HAR{Log: Log{
+		Version: "1.2",
+		Creator: Creator{Name: "httpclient", Version: "1"},
+		Entries: append([]Entry{}, r.entries...),
+	}}
+}
+
+// Save writes an HTTP Archive of all the entries recorded so far to the file
+// at the passed path
+func (r *Recorder) Save(path string) error { return r.HAR().Save(path) }
+
+// entry creates an Entry from a single attempt
+func (r *Recorder) entry(req *http.Request, res *http.Response, body []byte,
+	timing httpclient.Timing, started time.Time, receive time.Duration) Entry {
+	// Credentials are always redacted, and other secrets when asked to
+	reqHeader, reqURL := httpclient.RedactCredentials(req)
+	resHeader := res.Header
+	if r.redact != nil {
+		reqHeader, reqURL = httpclient.RedactRequest(req, r.redact...)
+		resHeader = httpclient.RedactHeader(resHeader, r.redact...)
+	}
+
+	e := Entry{
+		StartedDateTime: started,
+		Time:            ms(timing.Total + receive),
+		Request: Request{
+			Method:      req.Method,
+			URL:         reqURL.String(),
+			HTTPVersion: req.Proto,
+			Cookies:     cookies((&http.Request{Header: reqHeader}).Cookies(), r.redact != nil),
+			Headers:     nameValues(reqHeader),
+			QueryString: nameValues(reqURL.Query()),
+			HeadersSize: -1,
+		},
+		Response: Response{
+			Status:      res.StatusCode,
+			StatusText:  http.StatusText(res.StatusCode),
+			HTTPVersion: res.Proto,
+			Cookies:     cookies(res.Cookies(), r.redact != nil),
+			Headers:     nameValues(resHeader),
+			Content: Content{
+				Size:     int64(len(body)),
+				MimeType: res.Header.Get("Content-Type"),
+			},
+			RedirectURL: res.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    int64(len(body)),
+		},
+		Timings:         timings(timing, receive),
+		ServerIPAddress: host(timing.RemoteAddr),
+	}
+	if r.redact != nil {
+		e.Request.Cookies, e.Response.Cookies = []Cookie{}, []Cookie{}
+	}
+
+	// Record the request body when it can be read again
+	if req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			b, _ := io.ReadAll(rc)
+			rc.Close()
+			if len(b) > 0 {
+				e.Request.BodySize = int64(len(b))
+				e.Request.PostData = &PostData{
+					MimeType: req.Header.Get("Content-Type"),
+					Text:     string(b),
+				}
+			}
+		}
+	}
+
+	if utf8.Valid(body) {
+		e.Response.Content.Text = string(body)
+	} else {
+		e.Response.Content.Text = base64.StdEncoding.EncodeToString(body)
+		e.Response.Content.Encoding = "base64"
+	}
+	return e
+}
+
+// timings converts an httpclient Timing to HAR Timings
+func timings(t httpclient.Timing, receive time.Duration) Timings {
+	out := Timings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Receive: ms(receive)}
+	if t.DNSLookup > 0 {
+		out.DNS = ms(t.DNSLookup)
+	}
+	if t.Connect > 0 || t.TLSHandshake > 0 {
+		out.Connect = ms(t.Connect + t.TLSHandshake) // Includes SSL
+	}
+	if t.TLSHandshake > 0 {
+		out.SSL = ms(t.TLSHandshake)
+	}
+	wait := t.Total
+	if t.FirstByte > 0 {
+		wait = t.FirstByte
+	}
+	if wait -= t.DNSLookup + t.Connect + t.TLSHandshake; wait > 0 {
+		out.Wait = ms(wait)
+	}
+	return out
+}
+
+// ms converts a duration to fractional milliseconds
+func ms(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+
+// nameValues converts a map of values to sorted NameValues
+func nameValues(m map[string][]string) []NameValue {
+	out := []NameValue{}
+	for name, values := range m {
+		for _, v := range values {
+			out = append(out, NameValue{Name: name, Value: v})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// cookies converts http Cookies to HAR Cookies, replacing their values when
+// redact is set
+func cookies(cs []*http.Cookie, redact bool) []Cookie {
+	out := []Cookie{}
+	for _, c := range cs {
+		hc := Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if redact {
+			hc.Value = httpclient.Redacted
+		}
+		if !c.Expires.IsZero() {
+			expires := c.Expires
+			hc.Expires = &expires
+		}
+		out = append(out, hc)
+	}
+	return out
+}
+
+// host strips the port from the passed address
+func host(addr string) string {
+	h, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return h
+}
@@ -0,0 +1,77 @@
+package har
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+
+	"github.com/s32x/httpclient"
+	"github.com/s32x/httpclient/internal/replay"
+)
+
+// Transport is an http RoundTripper that replays the responses recorded in
+// an HTTP Archive. Requests are matched to entries by method, URL and body,
+// identical requests being answered by their entries in the order they were
+// recorded. Requests matching no entry fail with an error
+type Transport struct {
+	entries []Entry
+	player  *replay.Player
+}
+
+// NewTransport creates a Transport replaying the passed HTTP Archive. Use it
+// with client.WithTransport(...) to replay traffic through a Client
+func NewTransport(h *HAR) *Transport {
+	return &Transport{
+		entries: h.Log.Entries,
+		player:  replay.NewPlayer(len(h.Log.Entries)),
+	}
+}
+
+// RoundTrip answers the passed http Request with the response of the first
+// matching entry that hasn't been replayed yet, or the last matching entry
+// once they all have
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := replay.ReadBody(req)
+	if err != nil {
+		return nil, err
+	}
+	// Entries are recorded with credentials redacted, so match them against
+	// the redacted URL
+	_, u := httpclient.RedactCredentials(req)
+	match := t.player.Next(func(i int) bool {
+		return matches(t.entries[i].Request, req.Method, u.String(), body)
+	})
+	if match < 0 {
+		return nil, fmt.Errorf("har: no entry recorded for %s %s", req.Method, u)
+	}
+	return response(t.entries[match].Response, req)
+}
+
+// matches reports whether the recorded Request matches the passed method,
+// URL and body
+func matches(r Request, method, url string, body []byte) bool {
+	if r.Method != method || r.URL != url {
+		return false
+	}
+	if r.PostData == nil {
+		return len(body) == 0
+	}
+	return r.PostData.Text == string(body)
+}
+
+// response converts a recorded Response to an http Response
+func response(r Response, req *http.Request) (*http.Response, error) {
+	body := []byte(r.Content.Text)
+	if r.Content.Encoding == "base64" {
+		b, err := base64.StdEncoding.DecodeString(r.Content.Text)
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+	header := http.Header{}
+	for _, h := range r.Headers {
+		header.Add(h.Name, h.Value)
+	}
+	return replay.Response(req, r.Status, r.StatusText, header, body), nil
+}
@@ -0,0 +1,390 @@
+// Package httpclienttest provides an expectation-based mock server for
+// testing code built on an httpclient Client
+package httpclienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/s32x/httpclient"
+)
+
+// TestingT is the subset of testing.TB used by a Server
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Cleanup(func())
+}
+
+// Server is an httptest Server that answers requests according to the
+// Expectations declared on it and reports any that weren't met
+type Server struct {
+	*httptest.Server
+	t TestingT
+
+	mu           sync.Mutex
+	expectations []*Expectation
+	unexpected   []string
+}
+
+// NewServer starts a new Server that is closed and verified when the test
+// finishes
+func NewServer(t TestingT) *Server {
+	s := &Server{t: t}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(func() {
+		s.Close()
+		s.Verify()
+	})
+	return s
+}
+
+// NewClient returns a new httpclient Client with its base URL pointed at the
+// Server. The embedded httptest Servers Client() still returns its http
+// Client
+func (s *Server) NewClient() *httpclient.Client {
+	return httpclient.New().WithBaseURL(s.URL)
+}
+
+// Expect declares that a request with the passed method and path is
+// expected. The path may include a query string, in which case it must match
+// too. Unless otherwise specified the request is expected at least once and
+// answered with a 200 and no body. Expectations must be fully declared
+// before any requests are made
+func (s *Server) Expect(method, path string) *Expectation {
+	e := &Expectation{
+		method: method,
+		path:   path,
+		header: http.Header{},
+		status: http.StatusOK,
+		min:    1,
+		max:    -1,
+	}
+	s.mu.Lock()
+	s.expectations = append(s.expectations, e)
+	s.mu.Unlock()
+	return e
+}
+
+// Verify reports every Expectation that wasn't met and every request that
+// didn't match an Expectation. It's called automatically when the test
+// finishes
+func (s *Server) Verify() {
+	s.t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.expectations {
+		if e.calls < e.min || (e.max >= 0 && e.calls > e.max) {
+			s.t.Errorf("httpclienttest: expected %s to be called %s, but it was called %d times", e, e.times(), e.calls)
+		}
+	}
+	for _, u := range s.unexpected {
+		s.t.Errorf("httpclienttest: %s", u)
+	}
+	s.unexpected = nil
+}
+
+// serveHTTP answers a request with the first matching Expectation that
+// hasn't been exhausted
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	var match, exhausted *Expectation
+	for _, e := range s.expectations {
+		if !e.matches(r, body) {
+			continue
+		}
+		if e.max >= 0 && e.calls >= e.max {
+			exhausted = e
+			continue
+		}
+		match = e
+		break
+	}
+	if match == nil {
+		msg := s.describeUnexpected(r, body, exhausted)
+		s.unexpected = append(s.unexpected, msg)
+		s.mu.Unlock()
+		http.Error(w, msg, http.StatusNotImplemented)
+		return
+	}
+	match.calls++
+	s.mu.Unlock()
+
+	for k, vs := range match.resHeader {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(match.status)
+	w.Write(match.resBody)
+}
+
+// describeUnexpected describes a request that matched no Expectation,
+// including a diff against the closest Expectation
+func (s *Server) describeUnexpected(r *http.Request, body []byte, exhausted *Expectation) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "unexpected request %s %s", r.Method, r.URL.RequestURI())
+	if exhausted != nil {
+		fmt.Fprintf(&b, ": %s was expected %s and has already been called %d times", exhausted, exhausted.times(), exhausted.calls)
+		return b.String()
+	}
+
+	var closest *Expectation
+	best := -1
+	for _, e := range s.expectations {
+		if score := e.score(r, body); score > best {
+			closest, best = e, score
+		}
+	}
+	if closest == nil {
+		b.WriteString(": no requests were expected")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "\nclosest expectation %s (- expected, + actual):\n", closest)
+	b.WriteString(diff(closest.describe(), describeRequest(r, body, closest)))
+	return b.String()
+}
+
+// Expectation is a request expected by a Server and the response it's
+// answered with
+type Expectation struct {
+	method, path string
+	header       http.Header
+	body         []byte
+	json         bool // Whether the body is compared as JSON
+
+	status    int
+	resHeader http.Header
+	resBody   []byte
+
+	min, max int // max is -1 when unlimited
+	calls    int
+}
+
+// WithHeader expects the request to have the passed header value
+func (e *Expectation) WithHeader(key, value string) *Expectation {
+	e.header.Add(key, value)
+	return e
+}
+
+// WithBody expects the request to have exactly the passed body
+func (e *Expectation) WithBody(body string) *Expectation {
+	e.body, e.json = []byte(body), false
+	return e
+}
+
+// WithJSON expects the request body to be JSON equivalent to the passed
+// value once encoded
+func (e *Expectation) WithJSON(v interface{}) *Expectation {
+	e.body, e.json = mustJSON(v), true
+	return e
+}
+
+// Reply sets the status the request is answered with
+func (e *Expectation) Reply(status int) *Expectation {
+	e.status = status
+	return e
+}
+
+// ReplyString sets the status and body the request is answered with
+func (e *Expectation) ReplyString(status int, body string) *Expectation {
+	e.status, e.resBody = status, []byte(body)
+	return e
+}
+
+// ReplyJSON sets the status and the JSON encoded body the request is
+// answered with
+func (e *Expectation) ReplyJSON(status int, v interface{}) *Expectation {
+	e.status, e.resBody = status, mustJSON(v)
+	return e.ReplyHeader("Content-Type", "application/json")
+}
+
+// ReplyHeader sets a header on the response the request is answered with
+func (e *Expectation) ReplyHeader(key, value string) *Expectation {
+	if e.resHeader == nil {
+		e.resHeader = http.Header{}
+	}
+	e.resHeader.Add(key, value)
+	return e
+}
+
+// Times expects the request exactly n times
+func (e *Expectation) Times(n int) *Expectation {
+	e.min, e.max = n, n
+	return e
+}
+
+// AtMost expects the request no more than n times
+func (e *Expectation) AtMost(n int) *Expectation {
+	e.min, e.max = 0, n
+	return e
+}
+
+// AtLeast expects the request n or more times
+func (e *Expectation) AtLeast(n int) *Expectation {
+	e.min, e.max = n, -1
+	return e
+}
+
+// String returns the method and path of the Expectation
+func (e *Expectation) String() string { return e.method + " " + e.path }
+
+// times describes how many times the Expectation is expected
+func (e *Expectation) times() string {
+	switch {
+	case e.min == e.max:
+		return fmt.Sprintf("exactly %d times", e.min)
+	case e.max < 0:
+		return fmt.Sprintf("at least %d times", e.min)
+	default:
+		return fmt.Sprintf("at most %d times", e.max)
+	}
+}
+
+// matches reports whether the passed request and body match the Expectation
+func (e *Expectation) matches(r *http.Request, body []byte) bool {
+	return e.score(r, body) == 4
+}
+
+// score returns how many of the method, path, headers and body of the passed
+// request match the Expectation
+func (e *Expectation) score(r *http.Request, body []byte) int {
+	score := 0
+	if r.Method == e.method {
+		score++
+	}
+	if e.matchesPath(r) {
+		score++
+	}
+	if e.matchesHeader(r.Header) {
+		score++
+	}
+	if e.matchesBody(body) {
+		score++
+	}
+	return score
+}
+
+// matchesPath compares the path, and the query when one is expected
+func (e *Expectation) matchesPath(r *http.Request) bool {
+	if strings.Contains(e.path, "?") {
+		return r.URL.RequestURI() == e.path
+	}
+	return r.URL.Path == e.path
+}
+
+// matchesHeader checks that all expected header values are present
+func (e *Expectation) matchesHeader(h http.Header) bool {
+	for k, vs := range e.header {
+		for _, v := range vs {
+			if !contains(h.Values(k), v) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchesBody compares the body, as JSON when a JSON body is expected
+func (e *Expectation) matchesBody(body []byte) bool {
+	if e.body == nil {
+		return true
+	}
+	if !e.json {
+		return bytes.Equal(e.body, body)
+	}
+	return bytes.Equal(e.body, canonicalJSON(body))
+}
+
+// describe renders the Expectation as the lines compared in a diff
+func (e *Expectation) describe() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", e.method, e.path)
+	writeHeader(&b, e.header)
+	if e.body != nil {
+		b.Write(e.body)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// describeRequest renders a request as the lines compared in a diff, only
+// including the headers the passed Expectation cares about
+func describeRequest(r *http.Request, body []byte, e *Expectation) string {
+	var b strings.Builder
+	path := r.URL.Path
+	if strings.Contains(e.path, "?") {
+		path = r.URL.RequestURI()
+	}
+	fmt.Fprintf(&b, "%s %s\n", r.Method, path)
+	h := http.Header{}
+	for k := range e.header {
+		if vs := r.Header.Values(k); len(vs) > 0 {
+			h[k] = vs
+		}
+	}
+	writeHeader(&b, h)
+	if e.body != nil {
+		if e.json {
+			body = canonicalJSON(body)
+		}
+		b.Write(body)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// writeHeader writes the passed header sorted, one value per line
+func writeHeader(b *strings.Builder, h http.Header) {
+	var lines []string
+	for k, vs := range h {
+		for _, v := range vs {
+			lines = append(lines, k+": "+v)
+		}
+	}
+	sort.Strings(lines)
+	for _, l := range lines {
+		b.WriteString(l + "\n")
+	}
+}
+
+// mustJSON encodes the passed value as canonical, indented JSON, panicking
+// if it can't be encoded as that's a mistake in the test itself
+func mustJSON(v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("httpclienttest: %v", err))
+	}
+	return canonicalJSON(b)
+}
+
+// canonicalJSON re-encodes the passed JSON with sorted keys and indentation
+// so that equivalent documents compare equal and diff line by line. Invalid
+// JSON is returned as is
+func canonicalJSON(b []byte) []byte {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return b
+	}
+	out, _ := json.MarshalIndent(v, "", "  ")
+	return out
+}
+
+// contains reports whether the passed slice contains the passed string
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package httpclienttest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestServer(t *testing.T) {
+	s := NewServer(t)
+	s.Expect(http.MethodPost, "/users").
+		WithHeader("Authorization", "Bearer token").
+		WithJSON(map[string]interface{}{"name": "gopher", "admin": false}).
+		ReplyJSON(http.StatusCreated, map[string]int{"id": 1}).
+		AtMost(2)
+	s.Expect(http.MethodGet, "/users?page=2").
+		ReplyString(http.StatusOK, "page two").
+		Times(1)
+
+	c := s.NewClient().WithHeader("Authorization", "Bearer token")
+	var created struct{ ID int }
+	err := c.Post("/users").
+		WithJSON(map[string]interface{}{"admin": false, "name": "gopher"}).
+		WithExpectedStatus(http.StatusCreated).
+		JSON(&created)
+	if err != nil || created.ID != 1 {
+		t.Errorf("Request.JSON() = %+v, %v, want id 1", created, err)
+	}
+	got, err := c.Get("/users?page=2").String()
+	if err != nil || got != "page two" {
+		t.Errorf("Request.String() = %q, %v, want %q", got, err, "page two")
+	}
+}
+
+func TestServer_Failures(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(s *Server)
+		want []string
+	}{
+		{
+			name: "unmet expectation",
+			run: func(s *Server) {
+				s.Expect(http.MethodGet, "/users").Times(2)
+				s.NewClient().Get("/users").Error()
+			},
+			want: []string{"expected GET /users to be called exactly 2 times, but it was called 1 times"},
+		},
+		{
+			name: "too many calls",
+			run: func(s *Server) {
+				s.Expect(http.MethodDelete, "/users/1").AtMost(1)
+				s.NewClient().Delete("/users/1").Error()
+				s.NewClient().Delete("/users/1").Error()
+			},
+			want: []string{"unexpected request DELETE /users/1: DELETE /users/1 was expected at most 1 times and has already been called 1 times"},
+		},
+		{
+			name: "unexpected body",
+			run: func(s *Server) {
+				s.Expect(http.MethodPost, "/users").WithJSON(map[string]string{"name": "gopher"})
+				s.NewClient().Post("/users").WithJSON(map[string]string{"name": "gofer"}).Error()
+			},
+			want: []string{
+				"unexpected request POST /users\nclosest expectation POST /users (- expected, + actual):\n" +
+					"  POST /users\n  {\n-   \"name\": \"gopher\"\n+   \"name\": \"gofer\"\n  }\n",
+				"expected POST /users to be called at least 1 times, but it was called 0 times",
+			},
+		},
+		{
+			name: "nothing expected",
+			run: func(s *Server) {
+				s.NewClient().Get("/").Error()
+			},
+			want: []string{"unexpected request GET /: no requests were expected"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft := &fakeT{}
+			s := NewServer(ft)
+			tt.run(s)
+			ft.cleanup()
+
+			if len(ft.errors) != len(tt.want) {
+				t.Fatalf("reported %q, want %q", ft.errors, tt.want)
+			}
+			for _, want := range tt.want {
+				found := false
+				for _, got := range ft.errors {
+					found = found || strings.TrimPrefix(got, "httpclienttest: ") == want
+				}
+				if !found {
+					t.Errorf("reported %q, want %q", ft.errors, want)
+				}
+			}
+		})
+	}
+}
+
+// fakeT is a TestingT that records reported errors
+type fakeT struct {
+	errors   []string
+	cleanups []func()
+}
+
+func (t *fakeT) Helper()                           {}
+func (t *fakeT) Cleanup(f func())                  { t.cleanups = append(t.cleanups, f) }
+func (t *fakeT) Errorf(f string, a ...interface{}) { t.errors = append(t.errors, fmt.Sprintf(f, a...)) }
+
+func (t *fakeT) cleanup() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
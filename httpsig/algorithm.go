@@ -0,0 +1,166 @@
+package httpsig
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+)
+
+// ErrInvalidSignature is returned when a signature doesn't match the
+// signature base it was verified against
+var ErrInvalidSignature = errors.New("httpsig: invalid signature")
+
+// errVerifyOnly is returned when signing with an Algorithm that only holds a
+// public key
+var errVerifyOnly = errors.New("httpsig: can't sign with a public key")
+
+// Algorithm signs and verifies signature bases with a key, as described in
+// RFC 9421 section 3.3
+type Algorithm interface {
+	// Name is the name of the Algorithm in the HTTP Signature Algorithms
+	// registry
+	Name() string
+	Sign(base []byte) ([]byte, error)
+	Verify(base, signature []byte) error
+}
+
+// HMACSHA256 returns the hmac-sha256 Algorithm using the passed shared secret
+func HMACSHA256(secret []byte) Algorithm { return hmacSHA256(secret) }
+
+// hmacSHA256 is the hmac-sha256 Algorithm
+type hmacSHA256 []byte
+
+func (hmacSHA256) Name() string { return "hmac-sha256" }
+
+func (k hmacSHA256) Sign(base []byte) ([]byte, error) {
+	h := hmac.New(sha256.New, k)
+	h.Write(base)
+	return h.Sum(nil), nil
+}
+
+func (k hmacSHA256) Verify(base, signature []byte) error {
+	want, _ := k.Sign(base)
+	if !hmac.Equal(want, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Ed25519 returns the ed25519 Algorithm that signs with the passed private
+// key
+func Ed25519(key ed25519.PrivateKey) Algorithm {
+	return ed25519Key{priv: key, pub: key.Public().(ed25519.PublicKey)}
+}
+
+// Ed25519PublicKey returns the ed25519 Algorithm that verifies with the
+// passed public key
+func Ed25519PublicKey(key ed25519.PublicKey) Algorithm { return ed25519Key{pub: key} }
+
+// ed25519Key is the ed25519 Algorithm
+type ed25519Key struct {
+	priv ed25519.PrivateKey
+	pub  ed25519.PublicKey
+}
+
+func (ed25519Key) Name() string { return "ed25519" }
+
+func (k ed25519Key) Sign(base []byte) ([]byte, error) {
+	if k.priv == nil {
+		return nil, errVerifyOnly
+	}
+	return ed25519.Sign(k.priv, base), nil
+}
+
+func (k ed25519Key) Verify(base, signature []byte) error {
+	if !ed25519.Verify(k.pub, base, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ECDSAP256 returns the ecdsa-p256-sha256 Algorithm that signs with the
+// passed private key
+func ECDSAP256(key *ecdsa.PrivateKey) Algorithm { return ecdsaKey{priv: key, pub: &key.PublicKey} }
+
+// ECDSAP256PublicKey returns the ecdsa-p256-sha256 Algorithm that verifies
+// with the passed public key
+func ECDSAP256PublicKey(key *ecdsa.PublicKey) Algorithm { return ecdsaKey{pub: key} }
+
+// ecdsaKey is the ecdsa-p256-sha256 Algorithm. Signatures are the 32 byte
+// big-endian r and s values concatenated, rather than ASN.1
+type ecdsaKey struct {
+	priv *ecdsa.PrivateKey
+	pub  *ecdsa.PublicKey
+}
+
+func (ecdsaKey) Name() string { return "ecdsa-p256-sha256" }
+
+func (k ecdsaKey) Sign(base []byte) ([]byte, error) {
+	if k.priv == nil {
+		return nil, errVerifyOnly
+	}
+	digest := sha256.Sum256(base)
+	r, s, err := ecdsa.Sign(rand.Reader, k.priv, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return sig, nil
+}
+
+func (k ecdsaKey) Verify(base, signature []byte) error {
+	if len(signature) != 64 {
+		return ErrInvalidSignature
+	}
+	digest := sha256.Sum256(base)
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(k.pub, digest[:], r, s) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// RSAPSS returns the rsa-pss-sha512 Algorithm that signs with the passed
+// private key
+func RSAPSS(key *rsa.PrivateKey) Algorithm { return rsaPSSKey{priv: key, pub: &key.PublicKey} }
+
+// RSAPSSPublicKey returns the rsa-pss-sha512 Algorithm that verifies with
+// the passed public key
+func RSAPSSPublicKey(key *rsa.PublicKey) Algorithm { return rsaPSSKey{pub: key} }
+
+// rsaPSSKey is the rsa-pss-sha512 Algorithm
+type rsaPSSKey struct {
+	priv *rsa.PrivateKey
+	pub  *rsa.PublicKey
+}
+
+// pssOptions are the RSASSA-PSS parameters required by RFC 9421
+var pssOptions = &rsa.PSSOptions{SaltLength: 64, Hash: crypto.SHA512}
+
+func (rsaPSSKey) Name() string { return "rsa-pss-sha512" }
+
+func (k rsaPSSKey) Sign(base []byte) ([]byte, error) {
+	if k.priv == nil {
+		return nil, errVerifyOnly
+	}
+	digest := sha512.Sum512(base)
+	return rsa.SignPSS(rand.Reader, k.priv, crypto.SHA512, digest[:], pssOptions)
+}
+
+func (k rsaPSSKey) Verify(base, signature []byte) error {
+	digest := sha512.Sum512(base)
+	if rsa.VerifyPSS(k.pub, crypto.SHA512, digest[:], signature, pssOptions) != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
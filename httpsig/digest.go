@@ -0,0 +1,128 @@
+package httpsig
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DigestAlgorithm is a hash algorithm used in a Content-Digest, as described
+// in RFC 9530
+type DigestAlgorithm string
+
+const (
+	// SHA256 is the sha-256 digest algorithm
+	SHA256 DigestAlgorithm = "sha-256"
+
+	// SHA512 is the sha-512 digest algorithm
+	SHA512 DigestAlgorithm = "sha-512"
+)
+
+var (
+	// ErrDigestMismatch is returned when a body doesn't match its
+	// Content-Digest
+	ErrDigestMismatch = errors.New("httpsig: content digest mismatch")
+
+	// errNoDigest is returned when a Content-Digest has no digest using a
+	// supported algorithm
+	errNoDigest = errors.New("httpsig: no supported content digest")
+
+	// errStreamDigest is returned when computing the digest of a request
+	// body that can't be read without consuming it
+	errStreamDigest = errors.New("httpsig: can't compute the content digest of a streamed body")
+)
+
+// newHash returns a new hash for the DigestAlgorithm, or nil when it's not
+// supported
+func (a DigestAlgorithm) newHash() hash.Hash {
+	switch a {
+	case SHA256:
+		return sha256.New()
+	case SHA512:
+		return sha512.New()
+	}
+	return nil
+}
+
+// digest returns the digest of the passed body
+func (a DigestAlgorithm) digest(body []byte) []byte {
+	h := a.newHash()
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// ContentDigest returns the Content-Digest header value of the passed body
+// using the passed algorithms, sha-256 when none are passed
+func ContentDigest(body []byte, algs ...DigestAlgorithm) string {
+	if len(algs) == 0 {
+		algs = []DigestAlgorithm{SHA256}
+	}
+	parts := make([]string, 0, len(algs))
+	for _, alg := range algs {
+		if alg.newHash() == nil {
+			continue
+		}
+		parts = append(parts, string(alg)+"=:"+base64.StdEncoding.EncodeToString(alg.digest(body))+":")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// VerifyContentDigest checks that every digest in the passed Content-Digest
+// header value using a supported algorithm matches the passed body, and that
+// there is at least one
+func VerifyContentDigest(header string, body []byte) error {
+	dict, err := parseDictionary(header)
+	if err != nil {
+		return err
+	}
+	verified := false
+	for _, m := range dict {
+		alg := DigestAlgorithm(m.name)
+		if alg.newHash() == nil {
+			continue
+		}
+		if m.value.bytes == nil || subtle.ConstantTimeCompare(alg.digest(body), m.value.bytes) != 1 {
+			return ErrDigestMismatch
+		}
+		verified = true
+	}
+	if !verified {
+		return errNoDigest
+	}
+	return nil
+}
+
+// requestBody returns the body of the passed http Request, reading it from
+// GetBody so the Request can still be sent
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody == nil {
+		return nil, errStreamDigest
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// readBody reads the passed body, returning a replacement that can be read
+// again
+func readBody(body io.ReadCloser) ([]byte, io.ReadCloser, error) {
+	if body == nil || body == http.NoBody {
+		return nil, body, nil
+	}
+	b, err := io.ReadAll(body)
+	body.Close()
+	return b, io.NopCloser(bytes.NewReader(b)), err
+}
@@ -0,0 +1,259 @@
+// Package httpsig signs requests made with an httpclient Client and verifies
+// signed responses and webhooks using HTTP Message Signatures (RFC 9421),
+// with the body covered by a Content-Digest (RFC 9530)
+package httpsig
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/s32x/httpclient"
+)
+
+// DefaultLabel is the label signatures are added under unless another is set
+const DefaultLabel = "sig1"
+
+// DefaultComponents are the components covered by a signature unless others
+// are set
+var DefaultComponents = []string{"@method", "@target-uri", "content-digest"}
+
+// Signer signs requests using HTTP Message Signatures
+type Signer struct {
+	keyID      string
+	alg        Algorithm
+	label      string
+	components []string
+	digests    []DigestAlgorithm
+	clock      httpclient.Clock
+	expiry     time.Duration
+	tag        string
+}
+
+// New creates a new Signer that signs requests with the passed Algorithm,
+// identifying the key with the passed key ID
+func New(keyID string, alg Algorithm) *Signer {
+	return &Signer{
+		keyID:      keyID,
+		alg:        alg,
+		label:      DefaultLabel,
+		components: DefaultComponents,
+		digests:    []DigestAlgorithm{SHA256},
+		clock:      httpclient.SystemClock,
+	}
+}
+
+// WithLabel sets the label the signature is added under
+func (s *Signer) WithLabel(label string) *Signer {
+	s.label = label
+	return s
+}
+
+// WithComponents sets the components covered by the signature, such as
+// "@method", "@target-uri", "@authority", "@path", "@query" or the lowercase
+// name of a header. Covering "content-digest" adds a Content-Digest of the
+// body to the request
+func (s *Signer) WithComponents(components ...string) *Signer {
+	s.components = components
+	return s
+}
+
+// WithDigest sets the algorithms used to compute the Content-Digest, sha-256
+// by default
+func (s *Signer) WithDigest(algs ...DigestAlgorithm) *Signer {
+	s.digests = algs
+	return s
+}
+
+// WithClock sets the Clock the creation time of signatures is read from
+func (s *Signer) WithClock(clock httpclient.Clock) *Signer {
+	s.clock = clock
+	return s
+}
+
+// WithExpiry sets how long signatures are valid for, adding an expires
+// parameter to them
+func (s *Signer) WithExpiry(d time.Duration) *Signer {
+	s.expiry = d
+	return s
+}
+
+// WithTag sets the application specific tag parameter of signatures
+func (s *Signer) WithTag(tag string) *Signer {
+	s.tag = tag
+	return s
+}
+
+// Middleware returns a Middleware that signs every attempt of a Request
+func (s *Signer) Middleware() httpclient.Middleware {
+	return func(next httpclient.Handler) httpclient.Handler {
+		return func(req *http.Request) (*httpclient.Response, error) {
+			req = req.Clone(req.Context())
+			if err := s.Sign(req, s.clock.Now()); err != nil {
+				return nil, err
+			}
+			return next(req)
+		}
+	}
+}
+
+// Sign signs the passed http Request as created at the passed time, adding
+// the Signature-Input and Signature headers, and the Content-Digest header
+// when it's covered
+func (s *Signer) Sign(req *http.Request, now time.Time) error {
+	for _, c := range s.components {
+		if c != "content-digest" {
+			continue
+		}
+		body, err := requestBody(req)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Digest", ContentDigest(body, s.digests...))
+	}
+
+	params := []param{{"created", integerItem(now.Unix())}}
+	if s.expiry > 0 {
+		params = append(params, param{"expires", integerItem(now.Add(s.expiry).Unix())})
+	}
+	params = append(params, param{"keyid", stringItem(s.keyID)})
+	if s.tag != "" {
+		params = append(params, param{"tag", stringItem(s.tag)})
+	}
+	list := make([]item, len(s.components))
+	for i, c := range s.components {
+		list[i] = item{value: stringItem(c)}
+	}
+	signatureParams := serializeInnerList(list, params)
+
+	base, err := signatureBase(requestMessage(req), s.components, signatureParams)
+	if err != nil {
+		return err
+	}
+	sig, err := s.alg.Sign([]byte(base))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Signature-Input", s.label+"="+signatureParams)
+	req.Header.Set("Signature", s.label+"=:"+base64.StdEncoding.EncodeToString(sig)+":")
+	return nil
+}
+
+// message is the parts of a request or response that a signature can cover
+type message struct {
+	method        string
+	url           *url.URL
+	header        http.Header
+	contentLength int64
+	status        int // Only set for responses
+}
+
+// requestMessage returns the message of the passed http Request, which may
+// be either outgoing or received by a server
+func requestMessage(req *http.Request) message {
+	u := *req.URL
+	if u.Host == "" {
+		u.Host = req.Host
+	}
+	if u.Scheme == "" {
+		u.Scheme = "http"
+		if req.TLS != nil {
+			u.Scheme = "https"
+		}
+	}
+	return message{method: req.Method, url: &u, header: req.Header, contentLength: req.ContentLength}
+}
+
+// responseMessage returns the message of the passed http Response
+func responseMessage(res *http.Response) message {
+	return message{header: res.Header, contentLength: res.ContentLength, status: res.StatusCode}
+}
+
+// errResponseComponent is returned when a signature over a response covers
+// a component that only exists on requests
+var errResponseComponent = errors.New("httpsig: component isn't available on a response")
+
+// component returns the value of the named component of the message
+func (m message) component(name string) (string, error) {
+	if m.status != 0 && strings.HasPrefix(name, "@") && name != "@status" {
+		return "", errResponseComponent
+	}
+	switch name {
+	case "@method":
+		return m.method, nil
+	case "@target-uri":
+		return m.url.String(), nil
+	case "@authority":
+		return authority(m.url), nil
+	case "@scheme":
+		return strings.ToLower(m.url.Scheme), nil
+	case "@request-target":
+		return m.url.RequestURI(), nil
+	case "@path":
+		if p := m.url.EscapedPath(); p != "" {
+			return p, nil
+		}
+		return "/", nil
+	case "@query":
+		return "?" + m.url.RawQuery, nil
+	case "@status":
+		if m.status == 0 {
+			return "", errors.New("httpsig: @status isn't available on a request")
+		}
+		return strconv.Itoa(m.status), nil
+	}
+	if strings.HasPrefix(name, "@") {
+		return "", fmt.Errorf("httpsig: unsupported component %q", name)
+	}
+
+	values := append([]string(nil), m.header.Values(name)...)
+	if len(values) == 0 && name == "content-length" && m.contentLength >= 0 {
+		values = []string{strconv.FormatInt(m.contentLength, 10)}
+	}
+	if len(values) == 0 {
+		return "", fmt.Errorf("httpsig: covered header %q is missing", name)
+	}
+	for i, v := range values {
+		values[i] = strings.TrimSpace(v)
+	}
+	return strings.Join(values, ", "), nil
+}
+
+// authority returns the lowercase host of the passed URL, without the port
+// when it's the default for the scheme
+func authority(u *url.URL) string {
+	host := strings.ToLower(u.Host)
+	switch {
+	case u.Scheme == "http" && strings.HasSuffix(host, ":80"):
+		return strings.TrimSuffix(host, ":80")
+	case u.Scheme == "https" && strings.HasSuffix(host, ":443"):
+		return strings.TrimSuffix(host, ":443")
+	}
+	return host
+}
+
+// signatureBase builds the signature base of the passed message covering the
+// passed components, as described in RFC 9421 section 2.5
+func signatureBase(m message, components []string, signatureParams string) (string, error) {
+	var b strings.Builder
+	seen := map[string]bool{}
+	for _, c := range components {
+		if c != strings.ToLower(c) || seen[c] {
+			return "", fmt.Errorf("httpsig: invalid component %q", c)
+		}
+		seen[c] = true
+		v, err := m.component(c)
+		if err != nil {
+			return "", err
+		}
+		stringItem(c).serialize(&b)
+		b.WriteString(": " + v + "\n")
+	}
+	b.WriteString(`"@signature-params": ` + signatureParams)
+	return b.String(), nil
+}
@@ -0,0 +1,308 @@
+package httpsig
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// The subset of RFC 8941 Structured Field Values used by Signature-Input,
+// Signature and Content-Digest: dictionaries whose members are items or inner
+// lists, with parameters. Decimals aren't supported
+
+// errInvalidField is returned when a structured field can't be parsed
+var errInvalidField = errors.New("httpsig: invalid structured field")
+
+// bareItem is a structured field value without parameters
+type bareItem struct {
+	kind    byte // 's'tring, 't'oken, 'b'yte sequence, 'i'nteger or '?' boolean
+	str     string
+	bytes   []byte
+	num     int64
+	boolean bool
+}
+
+// param is a single parameter of an item or inner list
+type param struct {
+	name  string
+	value bareItem
+}
+
+// item is a bare item with its parameters
+type item struct {
+	value  bareItem
+	params []param
+}
+
+// member is a single member of a dictionary, either an item or an inner list
+type member struct {
+	name   string
+	value  bareItem
+	list   []item
+	isList bool
+	params []param
+}
+
+// param returns the value of the named parameter of the member
+func (m member) param(name string) (bareItem, bool) {
+	for _, p := range m.params {
+		if p.name == name {
+			return p.value, true
+		}
+	}
+	return bareItem{}, false
+}
+
+// stringItem, integerItem, bytesItem, booleanItem and tokenItem create bare
+// items of each kind
+func stringItem(s string) bareItem { return bareItem{kind: 's', str: s} }
+func integerItem(n int64) bareItem { return bareItem{kind: 'i', num: n} }
+func bytesItem(b []byte) bareItem  { return bareItem{kind: 'b', bytes: b} }
+func booleanItem(b bool) bareItem  { return bareItem{kind: '?', boolean: b} }
+func tokenItem(s string) bareItem  { return bareItem{kind: 't', str: s} }
+
+// serialize writes the bare item
+func (b bareItem) serialize(sb *strings.Builder) {
+	switch b.kind {
+	case 's':
+		sb.WriteByte('"')
+		for i := 0; i < len(b.str); i++ {
+			if b.str[i] == '"' || b.str[i] == '\\' {
+				sb.WriteByte('\\')
+			}
+			sb.WriteByte(b.str[i])
+		}
+		sb.WriteByte('"')
+	case 't':
+		sb.WriteString(b.str)
+	case 'b':
+		sb.WriteString(":" + base64.StdEncoding.EncodeToString(b.bytes) + ":")
+	case 'i':
+		sb.WriteString(strconv.FormatInt(b.num, 10))
+	case '?':
+		if b.boolean {
+			sb.WriteString("?1")
+		} else {
+			sb.WriteString("?0")
+		}
+	}
+}
+
+// serializeParams writes the passed parameters
+func serializeParams(sb *strings.Builder, params []param) {
+	for _, p := range params {
+		sb.WriteString(";" + p.name)
+		if p.value.kind != '?' || !p.value.boolean {
+			sb.WriteByte('=')
+			p.value.serialize(sb)
+		}
+	}
+}
+
+// serializeInnerList serializes an inner list and its parameters
+func serializeInnerList(list []item, params []param) string {
+	var sb strings.Builder
+	sb.WriteByte('(')
+	for i, it := range list {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		it.value.serialize(&sb)
+		serializeParams(&sb, it.params)
+	}
+	sb.WriteByte(')')
+	serializeParams(&sb, params)
+	return sb.String()
+}
+
+// parser parses structured fields
+type parser struct {
+	s string
+	i int
+}
+
+// parseDictionary parses a structured field dictionary
+func parseDictionary(s string) ([]member, error) {
+	p := &parser{s: s}
+	p.skip(" ")
+	var dict []member
+	for p.i < len(p.s) {
+		name, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		m := member{name: name, value: booleanItem(true)}
+		if p.peek() == '=' {
+			p.i++
+			if p.peek() == '(' {
+				m.isList = true
+				if m.list, err = p.innerList(); err != nil {
+					return nil, err
+				}
+			} else if m.value, err = p.bareItem(); err != nil {
+				return nil, err
+			}
+		}
+		if m.params, err = p.params(); err != nil {
+			return nil, err
+		}
+		dict = append(dict, m)
+
+		p.skip(" \t")
+		if p.i == len(p.s) {
+			break
+		}
+		if p.peek() != ',' {
+			return nil, errInvalidField
+		}
+		p.i++
+		p.skip(" \t")
+		if p.i == len(p.s) {
+			return nil, errInvalidField
+		}
+	}
+	return dict, nil
+}
+
+// peek returns the next byte, or 0 at the end of the input
+func (p *parser) peek() byte {
+	if p.i < len(p.s) {
+		return p.s[p.i]
+	}
+	return 0
+}
+
+// skip skips any of the passed characters
+func (p *parser) skip(chars string) {
+	for p.i < len(p.s) && strings.IndexByte(chars, p.s[p.i]) >= 0 {
+		p.i++
+	}
+}
+
+// key parses a dictionary or parameter key
+func (p *parser) key() (string, error) {
+	start := p.i
+	if c := p.peek(); !(c >= 'a' && c <= 'z' || c == '*') {
+		return "", errInvalidField
+	}
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.IndexByte("_-.*", c) >= 0) {
+			break
+		}
+		p.i++
+	}
+	return p.s[start:p.i], nil
+}
+
+// innerList parses an inner list, without its parameters
+func (p *parser) innerList() ([]item, error) {
+	p.i++ // (
+	var list []item
+	for {
+		p.skip(" ")
+		if p.peek() == ')' {
+			p.i++
+			return list, nil
+		}
+		v, err := p.bareItem()
+		if err != nil {
+			return nil, err
+		}
+		params, err := p.params()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, item{value: v, params: params})
+		if c := p.peek(); c != ' ' && c != ')' {
+			return nil, errInvalidField
+		}
+	}
+}
+
+// params parses parameters
+func (p *parser) params() ([]param, error) {
+	var params []param
+	for p.peek() == ';' {
+		p.i++
+		p.skip(" ")
+		name, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		v := booleanItem(true)
+		if p.peek() == '=' {
+			p.i++
+			if v, err = p.bareItem(); err != nil {
+				return nil, err
+			}
+		}
+		params = append(params, param{name: name, value: v})
+	}
+	return params, nil
+}
+
+// bareItem parses a bare item
+func (p *parser) bareItem() (bareItem, error) {
+	switch c := p.peek(); {
+	case c == '"':
+		return p.string()
+	case c == ':':
+		end := strings.IndexByte(p.s[p.i+1:], ':')
+		if end < 0 {
+			return bareItem{}, errInvalidField
+		}
+		b, err := base64.StdEncoding.DecodeString(p.s[p.i+1 : p.i+1+end])
+		if err != nil {
+			return bareItem{}, errInvalidField
+		}
+		p.i += end + 2
+		return bytesItem(b), nil
+	case c == '?':
+		if p.i+1 >= len(p.s) || (p.s[p.i+1] != '0' && p.s[p.i+1] != '1') {
+			return bareItem{}, errInvalidField
+		}
+		p.i += 2
+		return booleanItem(p.s[p.i-1] == '1'), nil
+	case c == '-' || c >= '0' && c <= '9':
+		start := p.i
+		p.i++
+		for p.i < len(p.s) && p.s[p.i] >= '0' && p.s[p.i] <= '9' {
+			p.i++
+		}
+		n, err := strconv.ParseInt(p.s[start:p.i], 10, 64)
+		if err != nil {
+			return bareItem{}, errInvalidField
+		}
+		return integerItem(n), nil
+	case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '*':
+		start := p.i
+		for p.i < len(p.s) && !strings.ContainsRune(" ,;()=\"\t", rune(p.s[p.i])) {
+			p.i++
+		}
+		return tokenItem(p.s[start:p.i]), nil
+	}
+	return bareItem{}, errInvalidField
+}
+
+// string parses a quoted string
+func (p *parser) string() (bareItem, error) {
+	var sb strings.Builder
+	for p.i++; p.i < len(p.s); p.i++ {
+		switch c := p.s[p.i]; c {
+		case '\\':
+			p.i++
+			if p.i == len(p.s) || (p.s[p.i] != '"' && p.s[p.i] != '\\') {
+				return bareItem{}, errInvalidField
+			}
+			sb.WriteByte(p.s[p.i])
+		case '"':
+			p.i++
+			return stringItem(sb.String()), nil
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return bareItem{}, errInvalidField
+}
@@ -0,0 +1,214 @@
+package httpsig
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/s32x/httpclient"
+)
+
+var (
+	// ErrNoSignature is returned when a message has no signature to verify
+	ErrNoSignature = errors.New("httpsig: no signature")
+
+	// ErrExpired is returned when a signature has expired or is older than
+	// the maximum age allowed
+	ErrExpired = errors.New("httpsig: signature expired")
+)
+
+// Verifier verifies HTTP Message Signatures on responses and on requests
+// received by a server, such as webhooks
+type Verifier struct {
+	keyID    string
+	alg      Algorithm
+	label    string
+	required []string
+	maxAge   time.Duration
+	clock    httpclient.Clock
+}
+
+// NewVerifier creates a new Verifier that verifies signatures made with the
+// passed Algorithm by the key with the passed key ID. Signatures with any key
+// ID are verified when it's empty. Unless other components are required with
+// WithRequiredComponents(...), signatures must cover the Content-Digest of
+// messages with a body, and the @method and @target-uri of requests
+func NewVerifier(keyID string, alg Algorithm) *Verifier {
+	return &Verifier{keyID: keyID, alg: alg, clock: httpclient.SystemClock}
+}
+
+// WithLabel sets the label of the signature that's verified. The first
+// signature by the key is verified by default
+func (v *Verifier) WithLabel(label string) *Verifier {
+	v.label = label
+	return v
+}
+
+// WithRequiredComponents sets the components a signature must cover to be
+// accepted, replacing the defaults. Calling it without any components accepts
+// signatures that cover nothing, leaving bodies unverified
+func (v *Verifier) WithRequiredComponents(components ...string) *Verifier {
+	v.required = append([]string{}, components...)
+	return v
+}
+
+// WithMaxAge rejects signatures created longer ago than the passed duration
+func (v *Verifier) WithMaxAge(d time.Duration) *Verifier {
+	v.maxAge = d
+	return v
+}
+
+// WithClock sets the Clock used to check the age and expiry of signatures
+func (v *Verifier) WithClock(clock httpclient.Clock) *Verifier {
+	v.clock = clock
+	return v
+}
+
+// Middleware returns a Middleware that verifies the signature of the
+// response to every attempt of a Request, failing the attempt when it's
+// invalid
+func (v *Verifier) Middleware() httpclient.Middleware {
+	return func(next httpclient.Handler) httpclient.Handler {
+		return func(req *http.Request) (*httpclient.Response, error) {
+			res, err := next(req)
+			if err != nil {
+				return res, err
+			}
+			return res, v.VerifyResponse(res.Response())
+		}
+	}
+}
+
+// VerifyResponse verifies the signature of the passed http Response, and its
+// Content-Digest when covered. The body is left ready to be read
+func (v *Verifier) VerifyResponse(res *http.Response) error {
+	hasBody := res.ContentLength != 0 && res.Body != nil && res.Body != http.NoBody
+	covered, err := v.verify(responseMessage(res), hasBody)
+	if err != nil || !covered {
+		return err
+	}
+	var body []byte
+	if body, res.Body, err = readBody(res.Body); err != nil {
+		return err
+	}
+	return VerifyContentDigest(res.Header.Get("Content-Digest"), body)
+}
+
+// VerifyRequest verifies the signature of the passed http Request received
+// by a server, and its Content-Digest when covered. The body is left ready to
+// be read
+func (v *Verifier) VerifyRequest(req *http.Request) error {
+	hasBody := req.ContentLength != 0 && req.Body != nil && req.Body != http.NoBody
+	covered, err := v.verify(requestMessage(req), hasBody)
+	if err != nil || !covered {
+		return err
+	}
+	var body []byte
+	if body, req.Body, err = readBody(req.Body); err != nil {
+		return err
+	}
+	return VerifyContentDigest(req.Header.Get("Content-Digest"), body)
+}
+
+// verify verifies the signature of the passed message, reporting whether
+// its Content-Digest is covered and so needs checking against the body
+func (v *Verifier) verify(m message, hasBody bool) (bool, error) {
+	inputs, err := parseDictionary(m.header.Get("Signature-Input"))
+	if err != nil {
+		return false, err
+	}
+	sigs, err := parseDictionary(m.header.Get("Signature"))
+	if err != nil {
+		return false, err
+	}
+	input, ok := v.choose(inputs)
+	if !ok {
+		return false, ErrNoSignature
+	}
+	var sig []byte
+	for _, s := range sigs {
+		if s.name == input.name && !s.isList && s.value.kind == 'b' {
+			sig = s.value.bytes
+		}
+	}
+	if sig == nil {
+		return false, ErrNoSignature
+	}
+
+	if alg, ok := input.param("alg"); ok && alg.str != v.alg.Name() {
+		return false, fmt.Errorf("httpsig: signature uses %s, not %s", alg.str, v.alg.Name())
+	}
+	if err := v.checkTime(input); err != nil {
+		return false, err
+	}
+
+	components := make([]string, len(input.list))
+	covered := map[string]bool{}
+	for i, it := range input.list {
+		if it.value.kind != 's' || len(it.params) > 0 {
+			return false, fmt.Errorf("httpsig: unsupported component in %s", input.name)
+		}
+		components[i] = it.value.str
+		covered[it.value.str] = true
+	}
+	for _, c := range v.requiredFor(m, hasBody) {
+		if !covered[c] {
+			return false, fmt.Errorf("httpsig: signature doesn't cover %q", c)
+		}
+	}
+
+	base, err := signatureBase(m, components, serializeInnerList(input.list, input.params))
+	if err != nil {
+		return false, err
+	}
+	if err := v.alg.Verify([]byte(base), sig); err != nil {
+		return false, err
+	}
+	return covered["content-digest"], nil
+}
+
+// requiredFor returns the components a signature over the passed message
+// must cover
+func (v *Verifier) requiredFor(m message, hasBody bool) []string {
+	if v.required != nil {
+		return v.required
+	}
+	var required []string
+	if m.status == 0 {
+		required = append(required, "@method", "@target-uri")
+	}
+	if hasBody {
+		required = append(required, "content-digest")
+	}
+	return required
+}
+
+// choose returns the Signature-Input member to verify
+func (v *Verifier) choose(inputs []member) (member, bool) {
+	for _, in := range inputs {
+		if !in.isList || (v.label != "" && in.name != v.label) {
+			continue
+		}
+		if keyID, _ := in.param("keyid"); v.keyID == "" || keyID.str == v.keyID {
+			return in, true
+		}
+	}
+	return member{}, false
+}
+
+// checkTime checks the creation and expiry times of a signature
+func (v *Verifier) checkTime(input member) error {
+	now := v.clock.Now()
+	if expires, ok := input.param("expires"); ok && now.Unix() > expires.num {
+		return ErrExpired
+	}
+	if v.maxAge <= 0 {
+		return nil
+	}
+	created, ok := input.param("created")
+	if !ok || now.Sub(time.Unix(created.num, 0)) > v.maxAge {
+		return ErrExpired
+	}
+	return nil
+}
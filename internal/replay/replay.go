@@ -0,0 +1,76 @@
+// Package replay holds the logic shared by the Transports that replay
+// recorded traffic
+package replay
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// Player chooses which of a fixed list of recorded exchanges answers each
+// request, replaying identical requests in the order they were recorded
+type Player struct {
+	mu   sync.Mutex
+	used []bool
+}
+
+// NewPlayer creates a Player for the passed number of recorded exchanges
+func NewPlayer(n int) *Player {
+	return &Player{used: make([]bool, n)}
+}
+
+// Next returns the index of the first exchange the passed func matches that
+// hasn't been replayed yet, or the last match once they all have. It returns
+// -1 when no exchange matches
+func (p *Player) Next(matches func(i int) bool) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	match := -1
+	for i := range p.used {
+		if !matches(i) {
+			continue
+		}
+		match = i
+		if !p.used[i] {
+			break
+		}
+	}
+	if match >= 0 {
+		p.used[match] = true
+	}
+	return match
+}
+
+// ReadBody reads and closes the body of the passed http Request
+func ReadBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	defer req.Body.Close()
+	return io.ReadAll(req.Body)
+}
+
+// Response creates the http Response answering the passed http Request with
+// a recorded status, header and body
+func Response(req *http.Request, statusCode int, statusText string, header http.Header, body []byte) *http.Response {
+	header = header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, statusText),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,115 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LogConfig configures the logging Middleware
+type LogConfig struct {
+	// RedactHeaders are headers redacted in addition to SensitiveHeaders
+	RedactHeaders []string
+
+	// RedactFields are JSON object fields redacted from captured bodies
+	RedactFields []string
+
+	// MaxBodySize is the number of bytes of request and response bodies
+	// captured in each record. Bodies aren't captured when it's zero
+	MaxBodySize int
+}
+
+// WithLogger adds a logging Middleware to the Client that writes a record to
+// the passed slog Logger for every attempt of every Request
+func (c *Client) WithLogger(logger *slog.Logger, config LogConfig) *Client {
+	return c.Use(Logging(logger, config))
+}
+
+// Logging returns a Middleware that writes a record to the passed slog Logger
+// for every attempt of a Request, redacting secrets as configured
+func Logging(logger *slog.Logger, config LogConfig) Middleware {
+	redactFields := jsonRedactor(config.RedactFields)
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*Response, error) {
+			header, u := RedactRequest(req, config.RedactHeaders...)
+			attrs := []slog.Attr{
+				slog.String("method", req.Method),
+				slog.String("url", u.String()),
+				slog.Int("attempt", AttemptFromContext(req.Context())),
+				slog.Int64("request_size", req.ContentLength),
+				headerAttr("request_headers", header, nil),
+			}
+			if config.MaxBodySize > 0 && req.GetBody != nil {
+				if body, err := req.GetBody(); err == nil {
+					b, _ := io.ReadAll(io.LimitReader(body, int64(config.MaxBodySize)))
+					body.Close()
+					attrs = append(attrs, slog.String("request_body", string(redactFields(b))))
+				}
+			}
+
+			start := time.Now()
+			res, err := next(req)
+			attrs = append(attrs, slog.Duration("duration", time.Since(start)))
+
+			level := slog.LevelInfo
+			if err != nil {
+				level = slog.LevelError
+				attrs = append(attrs, slog.String("error", err.Error()))
+			} else {
+				attrs = append(attrs,
+					slog.Int("status", res.res.StatusCode),
+					slog.Int64("response_size", res.res.ContentLength),
+					headerAttr("response_headers", res.res.Header, config.RedactHeaders),
+				)
+				if config.MaxBodySize > 0 {
+					b := peekBody(res.res, config.MaxBodySize)
+					attrs = append(attrs, slog.String("response_body", string(redactFields(b))))
+				}
+			}
+			logger.LogAttrs(req.Context(), level, "http request", attrs...)
+			return res, err
+		}
+	}
+}
+
+// headerAttr returns a slog Attr grouping the redacted values of the passed
+// http Header
+func headerAttr(key string, h http.Header, redact []string) slog.Attr {
+	h = RedactHeader(h, redact...)
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]any, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, slog.String(k, strings.Join(h[k], ", ")))
+	}
+	return slog.Group(key, attrs...)
+}
+
+// peekBody returns up to n bytes from the start of the passed http Responses
+// body without consuming them
+func peekBody(res *http.Response, n int) []byte {
+	b, _ := io.ReadAll(io.LimitReader(res.Body, int64(n)))
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(b), res.Body), res.Body}
+	return b
+}
+
+// attemptKey is the context key holding the attempt number of a Request
+type attemptKey struct{}
+
+// AttemptFromContext returns the number of the attempt (starting at 1) that
+// the context of an http Request passed to a Middleware belongs to
+func AttemptFromContext(ctx context.Context) int {
+	attempt, _ := ctx.Value(attemptKey{}).(int)
+	return attempt
+}
@@ -0,0 +1,79 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_WithLogger(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cr3t"})
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		w.Write([]byte(`{"token":"abc123","name":"gopher"}`))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	c := New().WithBaseURL(srv.URL).
+		WithClock(&fakeClock{}).
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, nil)), LogConfig{
+			RedactHeaders: []string{"X-Api-Key"},
+			RedactFields:  []string{"password", "token"},
+			MaxBodySize:   1024,
+		}).
+		WithHeader("Authorization", "Bearer hunter2").
+		WithHeader("X-Api-Key", "key123")
+
+	got, err := c.Post("/").WithJSON(map[string]string{"user": "gopher", "password": "pa55"}).String()
+	if err != nil {
+		t.Fatalf("Request.String() error = %v", err)
+	}
+	if want := `{"token":"abc123","name":"gopher"}`; got != want {
+		t.Errorf("Request.String() = %q, want %q (body consumed by logger?)", got, want)
+	}
+	c.Get("/fail").WithRetryPolicy(RetryOnServerError).WithRetry(2).Error()
+
+	for _, secret := range []string{"hunter2", "key123", "pa55", "abc123", "s3cr3t"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("log output contains secret %q:\n%s", secret, buf.String())
+		}
+	}
+
+	type record struct {
+		Method         string            `json:"method"`
+		Status         int               `json:"status"`
+		Attempt        int               `json:"attempt"`
+		RequestBody    string            `json:"request_body"`
+		ResponseBody   string            `json:"response_body"`
+		RequestHeaders map[string]string `json:"request_headers"`
+	}
+	var records []record
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var rec record
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, rec)
+	}
+	if len(records) != 3 {
+		t.Fatalf("logged %v records, want 3", len(records))
+	}
+	if rec := records[0]; rec.Method != http.MethodPost || rec.Status != http.StatusOK || rec.Attempt != 1 ||
+		rec.RequestBody != `{"password":"[REDACTED]","user":"gopher"}`+"\n" ||
+		rec.ResponseBody != `{"token":"[REDACTED]","name":"gopher"}` ||
+		rec.RequestHeaders["Authorization"] != Redacted {
+		t.Errorf("first record = %+v", rec)
+	}
+	if rec := records[2]; rec.Status != http.StatusServiceUnavailable || rec.Attempt != 2 {
+		t.Errorf("last record = %+v, want a 503 on attempt 2", rec)
+	}
+}
@@ -0,0 +1,82 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// Handler performs a single attempt of a Request, given the http Request
+// built from it, and returns the Response
+type Handler func(req *http.Request) (*Response, error)
+
+// Middleware wraps a Handler, allowing behaviour such as authentication,
+// logging, metrics or caching to be layered around every attempt of a Request
+type Middleware func(next Handler) Handler
+
+// errNoResponse is returned when a Handler returns neither a Response nor an
+// error
+var errNoResponse = errors.New("handler returned no response")
+
+// Use appends Middleware to the Client that will wrap every attempt of every
+// Request created by it. Middleware runs in the order it was added, so the
+// first Middleware added is the outermost, and always runs before any
+// Middleware added to the Request itself
+func (c *Client) Use(mw ...Middleware) *Client {
+	c.middleware = append(c.middleware, mw...)
+	return c
+}
+
+// Use appends Middleware to the Request that will wrap every attempt of it.
+// Middleware runs in the order it was added, after any Middleware added to
+// the Client
+func (r *Request) Use(mw ...Middleware) *Request {
+	r.middleware = append(r.middleware, mw...)
+	return r
+}
+
+// handler returns the Handler that performs a single attempt of the Request,
+// wrapped in all of its Middleware and authenticated with its TokenSource or
+// digest credentials
+func (r *Request) handler() Handler {
+	h := r.send
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		h = r.middleware[i](h)
+	}
+	h = r.authenticate(r.digestAuthenticate(h))
+	return func(req *http.Request) (*Response, error) {
+		res, err := h(req)
+		switch {
+		case err != nil && res != nil:
+			discard(res.res)
+			return nil, err
+		case err == nil && res == nil:
+			return nil, errNoResponse
+		}
+		return res, err
+	}
+}
+
+// send performs a single attempt of the Request using the standard library
+func (r *Request) send(req *http.Request) (*Response, error) {
+	client := r.client
+	if creds := credentialsFromRequest(req); len(creds) > 0 {
+		// Copy the client so credentials are dropped on cross-origin redirects
+		// without modifying the shared http Client
+		c := *client
+		c.CheckRedirect = checkRedirect(creds, client.CheckRedirect)
+		client = &c
+	}
+	res, err := client.Do(traceRequest(req))
+	if err != nil {
+		// Errors from the http Client hold the URL, so redact any credentials
+		// in it before the error is returned or logged
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			_, u := RedactCredentials(req)
+			urlErr.URL = u.String()
+		}
+		return nil, err
+	}
+	return &Response{res: res, timing: attemptTiming(req)}, nil
+}
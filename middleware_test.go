@@ -0,0 +1,105 @@
+package httpclient
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware_Order(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Trace")))
+	}))
+	defer srv.Close()
+
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(req *http.Request) (*Response, error) {
+				order = append(order, name+" before")
+				req.Header.Add("X-Trace", name)
+				res, err := next(req)
+				order = append(order, name+" after")
+				return res, err
+			}
+		}
+	}
+
+	got, err := New().WithBaseURL(srv.URL).
+		Use(trace("client 1"), trace("client 2")).
+		Get("/").
+		Use(trace("request")).
+		String()
+	if err != nil {
+		t.Fatalf("Request.String() error = %v", err)
+	}
+	if want := "client 1"; got != want {
+		t.Errorf("Request.String() = %q, want %q", got, want)
+	}
+	want := []string{
+		"client 1 before", "client 2 before", "request before",
+		"request after", "client 2 after", "client 1 after",
+	}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("Middleware ran in order %v, want %v", order, want)
+	}
+}
+
+func TestMiddleware_ShortCircuit(t *testing.T) {
+	cached := func(next Handler) Handler {
+		return func(req *http.Request) (*Response, error) {
+			return &Response{res: &http.Response{
+				StatusCode: http.StatusOK,
+				Status:     "200 OK",
+				Header:     http.Header{},
+				Body:       ioutil.NopCloser(strings.NewReader("cached")),
+				Request:    req,
+			}}, nil
+		}
+	}
+	got, err := New().Use(cached).Get("http://127.0.0.1:0/").String()
+	if err != nil {
+		t.Fatalf("Request.String() error = %v", err)
+	}
+	if got != "cached" {
+		t.Errorf("Request.String() = %q, want %q", got, "cached")
+	}
+}
+
+func TestMiddleware_EveryAttempt(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	attempts := 0
+	count := func(next Handler) Handler {
+		return func(req *http.Request) (*Response, error) {
+			attempts++
+			return next(req)
+		}
+	}
+	New().WithBaseURL(srv.URL).
+		WithClock(&fakeClock{}).
+		WithRetryPolicy(RetryOnServerError).
+		Use(count).
+		Get("/").
+		WithRetry(3).
+		Error()
+	if attempts != 3 {
+		t.Errorf("Middleware ran %v times, want 3", attempts)
+	}
+}
+
+func TestMiddleware_NoResponse(t *testing.T) {
+	empty := func(next Handler) Handler {
+		return func(req *http.Request) (*Response, error) { return nil, nil }
+	}
+	if _, err := New().Use(empty).Get("http://127.0.0.1:0/").Do(); !errors.Is(err, errNoResponse) {
+		t.Errorf("Request.Do() error = %v, want %v", err, errNoResponse)
+	}
+}
@@ -0,0 +1,205 @@
+// Package oauth2 provides httpclient TokenSources for the OAuth 2.0
+// client credentials and refresh token grants, for machine to machine
+// authentication without depending on golang.org/x/oauth2
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/s32x/httpclient"
+)
+
+// AuthStyle is how the client authenticates with the token endpoint
+type AuthStyle int
+
+const (
+	// AuthStyleBasic sends the client credentials using HTTP Basic
+	// authentication (client_secret_basic)
+	AuthStyleBasic AuthStyle = iota
+
+	// AuthStylePost sends the client credentials in the form body
+	// (client_secret_post)
+	AuthStylePost
+)
+
+// Config describes a client registered with an OAuth 2.0 authorization server
+type Config struct {
+	// TokenURL is the token endpoint. It's resolved against the base URL of
+	// Client, if it has one
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	AuthStyle    AuthStyle
+
+	// Scopes are the scopes requested, if any
+	Scopes []string
+
+	// Audience is the audience requested, if any
+	Audience string
+
+	// Params are any extra parameters sent to the token endpoint
+	Params url.Values
+
+	// Client is used to call the token endpoint, a new httpclient Client when
+	// nil. It mustn't itself use a TokenSource from this Config
+	Client *httpclient.Client
+}
+
+// Error is an error response from the token endpoint, as described in
+// RFC 6749 section 5.2
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+	URI         string `json:"error_uri"`
+
+	// Err is the StatusError of the response the Error was read from
+	Err *httpclient.StatusError `json:"-"`
+}
+
+// Error returns the OAuth error code and description
+func (e *Error) Error() string {
+	msg := "oauth2: " + e.Code
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	return msg
+}
+
+// Unwrap returns the StatusError of the response the Error was read from
+func (e *Error) Unwrap() error { return e.Err }
+
+// tokenResponse is a successful response from the token endpoint
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// errNoAccessToken is returned when the token endpoint responds without an
+// access token
+var errNoAccessToken = errors.New("oauth2: token response has no access_token")
+
+// ClientCredentials returns a TokenSource that fetches Tokens using the
+// client credentials grant
+func (c *Config) ClientCredentials() httpclient.TokenSource {
+	return httpclient.TokenSourceFunc(func(ctx context.Context) (*httpclient.Token, error) {
+		tok, _, err := c.fetch(ctx, url.Values{"grant_type": {"client_credentials"}})
+		return tok, err
+	})
+}
+
+// RefreshTokenSource is a TokenSource that fetches Tokens using the refresh
+// token grant, keeping any new refresh token the server issues
+type RefreshTokenSource struct {
+	config *Config
+
+	mu           sync.Mutex
+	refreshToken string
+}
+
+// RefreshToken returns a TokenSource that fetches Tokens using the refresh
+// token grant, starting with the passed refresh token
+func (c *Config) RefreshToken(refreshToken string) *RefreshTokenSource {
+	return &RefreshTokenSource{config: c, refreshToken: refreshToken}
+}
+
+// Token fetches a new Token using the current refresh token
+func (s *RefreshTokenSource) Token(ctx context.Context) (*httpclient.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tok, refreshToken, err := s.config.fetch(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {s.refreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if refreshToken != "" {
+		s.refreshToken = refreshToken
+	}
+	return tok, nil
+}
+
+// RefreshToken returns the current refresh token, which changes whenever the
+// server rotates it, so that it can be persisted
+func (s *RefreshTokenSource) RefreshToken() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refreshToken
+}
+
+// fetch calls the token endpoint with the passed grant parameters, returning
+// the Token and any refresh token issued
+func (c *Config) fetch(ctx context.Context, params url.Values) (*httpclient.Token, string, error) {
+	form := url.Values{}
+	for k, vs := range c.Params {
+		form[k] = append([]string(nil), vs...)
+	}
+	for k, vs := range params {
+		form[k] = vs
+	}
+	if len(c.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	if c.Audience != "" {
+		form.Set("audience", c.Audience)
+	}
+
+	client := c.Client
+	if client == nil {
+		client = httpclient.New()
+	}
+	req := client.Post(c.TokenURL).
+		WithContext(ctx).
+		WithHeader("Accept", "application/json").
+		WithExpectedStatus(http.StatusOK)
+	switch c.AuthStyle {
+	case AuthStylePost:
+		form.Set("client_id", c.ClientID)
+		if c.ClientSecret != "" {
+			form.Set("client_secret", c.ClientSecret)
+		}
+	default:
+		// RFC 6749 section 2.3.1 requires the credentials to be form encoded
+		req = req.WithBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+	}
+
+	var res tokenResponse
+	if err := req.WithForm(form).JSON(&res); err != nil {
+		return nil, "", tokenError(err)
+	}
+	if res.AccessToken == "" {
+		return nil, "", errNoAccessToken
+	}
+	tok := &httpclient.Token{AccessToken: res.AccessToken, TokenType: res.TokenType}
+	if strings.EqualFold(tok.TokenType, "bearer") {
+		tok.TokenType = "Bearer"
+	}
+	if res.ExpiresIn > 0 {
+		tok.Expiry = time.Now().Add(time.Duration(res.ExpiresIn) * time.Second)
+	}
+	return tok, res.RefreshToken, nil
+}
+
+// tokenError converts an unexpected status from the token endpoint into an
+// Error when its body is a standard OAuth error response
+func tokenError(err error) error {
+	var se *httpclient.StatusError
+	if !errors.As(err, &se) {
+		return fmt.Errorf("oauth2: %w", err)
+	}
+	e := &Error{Err: se}
+	if json.Unmarshal(se.Body, e) != nil || e.Code == "" {
+		return fmt.Errorf("oauth2: %w", err)
+	}
+	return e
+}
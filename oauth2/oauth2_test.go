@@ -0,0 +1,184 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/s32x/httpclient"
+	"github.com/s32x/httpclient/httpclienttest"
+)
+
+func TestClientCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		wantAuth string
+		wantBody string
+	}{
+		{
+			name:     "client_secret_basic",
+			config:   Config{ClientID: "id", ClientSecret: "s3cret"},
+			wantAuth: "Basic aWQ6czNjcmV0",
+			wantBody: "grant_type=client_credentials",
+		},
+		{
+			name:     "client_secret_basic escapes credentials",
+			config:   Config{ClientID: "id:1", ClientSecret: "a b"},
+			wantAuth: "Basic aWQlM0ExOmErYg==",
+			wantBody: "grant_type=client_credentials",
+		},
+		{
+			name:     "client_secret_post",
+			config:   Config{ClientID: "id", ClientSecret: "s3cret", AuthStyle: AuthStylePost},
+			wantBody: "client_id=id&client_secret=s3cret&grant_type=client_credentials",
+		},
+		{
+			name: "scopes, audience and params",
+			config: Config{
+				ClientID:     "id",
+				ClientSecret: "s3cret",
+				Scopes:       []string{"read", "write"},
+				Audience:     "https://api.example.com",
+				Params:       url.Values{"resource": {"orders"}},
+			},
+			wantAuth: "Basic aWQ6czNjcmV0",
+			wantBody: "audience=https%3A%2F%2Fapi.example.com&grant_type=client_credentials&resource=orders&scope=read+write",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httpclienttest.NewServer(t)
+			e := srv.Expect(http.MethodPost, "/token").
+				WithHeader("Content-Type", "application/x-www-form-urlencoded").
+				WithBody(tt.wantBody).
+				ReplyJSON(http.StatusOK, map[string]interface{}{
+					"access_token": "abc",
+					"token_type":   "bearer",
+					"expires_in":   3600,
+				})
+			if tt.wantAuth != "" {
+				e.WithHeader("Authorization", tt.wantAuth)
+			}
+
+			tt.config.TokenURL = "/token"
+			tt.config.Client = srv.NewClient()
+			tok, err := tt.config.ClientCredentials().Token(context.Background())
+			if err != nil {
+				t.Fatalf("Token() error = %v", err)
+			}
+			if tok.AccessToken != "abc" || tok.TokenType != "Bearer" {
+				t.Errorf("Token() = %+v, want a Bearer abc token", tok)
+			}
+			if d := time.Until(tok.Expiry); d < 59*time.Minute || d > time.Hour {
+				t.Errorf("Token() expires in %v, want an hour", d)
+			}
+		})
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	srv := httpclienttest.NewServer(t)
+	srv.Expect(http.MethodPost, "/token").
+		WithBody("grant_type=refresh_token&refresh_token=first").
+		ReplyJSON(http.StatusOK, map[string]interface{}{"access_token": "one", "refresh_token": "second"}).
+		Times(1)
+	srv.Expect(http.MethodPost, "/token").
+		WithBody("grant_type=refresh_token&refresh_token=second").
+		ReplyJSON(http.StatusOK, map[string]interface{}{"access_token": "two"}).
+		Times(2)
+
+	c := &Config{TokenURL: "/token", ClientID: "id", ClientSecret: "s3cret", Client: srv.NewClient()}
+	ts := c.RefreshToken("first")
+	for _, want := range []string{"one", "two", "two"} {
+		tok, err := ts.Token(context.Background())
+		if err != nil {
+			t.Fatalf("Token() error = %v", err)
+		}
+		if tok.AccessToken != want {
+			t.Errorf("Token() = %q, want %q", tok.AccessToken, want)
+		}
+		if !tok.Expiry.IsZero() {
+			t.Errorf("Token() expiry = %v, want none", tok.Expiry)
+		}
+	}
+	if got := ts.RefreshToken(); got != "second" {
+		t.Errorf("RefreshToken() = %q, want %q", got, "second")
+	}
+}
+
+func TestTokenError(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantOAuth *Error
+	}{
+		{
+			name:   "oauth error",
+			status: http.StatusBadRequest,
+			body:   `{"error":"invalid_client","error_description":"Client authentication failed"}`,
+			wantOAuth: &Error{
+				Code:        "invalid_client",
+				Description: "Client authentication failed",
+			},
+		},
+		{
+			name:   "not an oauth error",
+			status: http.StatusBadGateway,
+			body:   "upstream unavailable",
+		},
+		{
+			name:   "no access token",
+			status: http.StatusOK,
+			body:   `{"token_type":"bearer"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httpclienttest.NewServer(t)
+			srv.Expect(http.MethodPost, "/token").ReplyString(tt.status, tt.body)
+
+			c := &Config{TokenURL: "/token", Client: srv.NewClient()}
+			_, err := c.ClientCredentials().Token(context.Background())
+			if err == nil {
+				t.Fatal("Token() error = nil, want an error")
+			}
+
+			var oe *Error
+			if errors.As(err, &oe) != (tt.wantOAuth != nil) {
+				t.Fatalf("Token() error = %v, want OAuth error %v", err, tt.wantOAuth)
+			}
+			if tt.wantOAuth != nil {
+				if oe.Code != tt.wantOAuth.Code || oe.Description != tt.wantOAuth.Description {
+					t.Errorf("Token() error = %+v, want %+v", oe, tt.wantOAuth)
+				}
+				var se *httpclient.StatusError
+				if !errors.As(err, &se) || se.StatusCode != tt.status {
+					t.Errorf("Token() error doesn't wrap a %d StatusError", tt.status)
+				}
+			}
+		})
+	}
+}
+
+func TestWithTokenSource(t *testing.T) {
+	srv := httpclienttest.NewServer(t)
+	srv.Expect(http.MethodPost, "/token").
+		ReplyJSON(http.StatusOK, map[string]interface{}{"access_token": "abc", "expires_in": 3600}).
+		Times(1)
+	srv.Expect(http.MethodGet, "/orders").
+		WithHeader("Authorization", "Bearer abc").
+		Times(2)
+
+	c := &Config{TokenURL: "/token", ClientID: "id", ClientSecret: "s3cret", Client: srv.NewClient()}
+	api := srv.NewClient().WithTokenSource(c.ClientCredentials())
+	for i := 0; i < 2; i++ {
+		if err := api.Get("/orders").WithExpectedStatus(http.StatusOK).Error(); err != nil {
+			t.Fatalf("Request.Error() error = %v", err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// WithPathParam sets the value of a {name} placeholder in the Requests path.
+// Values are escaped so they always stay within their path segment, and once
+// any are set every placeholder must have a value and every value a
+// placeholder
+func (r *Request) WithPathParam(name, value string) *Request {
+	if r.pathParams == nil {
+		r.pathParams = map[string]string{}
+	}
+	r.pathParams[name] = value
+	return r
+}
+
+// WithPathParams sets the values of {name} placeholders in the Requests path
+// as with WithPathParam(...)
+func (r *Request) WithPathParams(params map[string]string) *Request {
+	if r.pathParams == nil {
+		r.pathParams = map[string]string{}
+	}
+	for name, value := range params {
+		r.WithPathParam(name, value)
+	}
+	return r
+}
+
+// expandPath returns the passed path with its {name} placeholders replaced
+// by the escaped values of the passed params. Placeholders are only
+// expanded before any query or fragment, and paths are left as they are
+// when no params were set so braces in them are sent as they always were
+func expandPath(path string, params map[string]string) (string, error) {
+	end := strings.IndexAny(path, "?#")
+	if end < 0 {
+		end = len(path)
+	}
+	if params == nil {
+		return path, nil
+	}
+
+	var b strings.Builder
+	used := map[string]bool{}
+	rest := path[:end]
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			b.WriteString(rest)
+			break
+		}
+		b.WriteString(rest[:start])
+		closing := strings.IndexByte(rest[start:], '}')
+		if closing < 0 {
+			return "", fmt.Errorf("path %q: unclosed placeholder", path)
+		}
+		name := rest[start+1 : start+closing]
+		value, ok := params[name]
+		if !ok {
+			return "", fmt.Errorf("path %q: missing value for {%s}", path, name)
+		}
+		if value == "" || value == "." || value == ".." {
+			return "", fmt.Errorf("path %q: invalid value %q for {%s}", path, value, name)
+		}
+		used[name] = true
+		b.WriteString(url.PathEscape(value))
+		rest = rest[start+closing+1:]
+	}
+	for name := range params {
+		if !used[name] {
+			return "", fmt.Errorf("path %q: no placeholder for path param %q", path, name)
+		}
+	}
+
+	expanded := b.String()
+	if err := checkPath(expanded); err != nil {
+		return "", fmt.Errorf("path %q: %w", path, err)
+	}
+	return expanded + path[end:], nil
+}
+
+// checkPath returns an error if the passed path contains dot segments,
+// which would change the endpoint the path resolves to
+func checkPath(path string) error {
+	for _, seg := range strings.Split(path, "/") {
+		if seg, err := url.PathUnescape(seg); err != nil {
+			return err
+		} else if seg == "." || seg == ".." {
+			return fmt.Errorf("dot segment %q", seg)
+		}
+	}
+	return nil
+}
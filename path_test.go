@@ -0,0 +1,84 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExpandPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		params  map[string]string
+		want    string
+		wantErr bool
+	}{
+		{name: "no placeholders", path: "/users", params: map[string]string{}, want: "/users"},
+		{name: "no params", path: "/search/{foo}/{", want: "/search/{foo}/{"},
+		{name: "single", path: "/users/{id}/repos", params: map[string]string{"id": "42"}, want: "/users/42/repos"},
+		{name: "several", path: "/{org}/{repo}.json", params: map[string]string{"org": "a b", "repo": "c"}, want: "/a%20b/c.json"},
+		{name: "slash escaped", path: "/users/{id}", params: map[string]string{"id": "a/b"}, want: "/users/a%2Fb"},
+		{name: "query escaped", path: "/users/{id}", params: map[string]string{"id": "a?admin=1#x"}, want: "/users/a%3Fadmin=1%23x"},
+		{name: "query kept", path: "/users/{id}?q={raw}", params: map[string]string{"id": "1"}, want: "/users/1?q={raw}"},
+		{name: "missing", path: "/users/{id}", params: map[string]string{}, wantErr: true},
+		{name: "unused", path: "/users/{id}", params: map[string]string{"id": "1", "other": "2"}, wantErr: true},
+		{name: "unclosed", path: "/users/{id", params: map[string]string{"id": "1"}, wantErr: true},
+		{name: "dot dot", path: "/users/{id}", params: map[string]string{"id": ".."}, wantErr: true},
+		{name: "dot", path: "/users/{id}", params: map[string]string{"id": "."}, wantErr: true},
+		{name: "empty", path: "/users/{id}/repos", params: map[string]string{"id": ""}, wantErr: true},
+		{name: "dot segment in template", path: "/users/../{id}", params: map[string]string{"id": "1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandPath(tt.path, tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("expandPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequest_WithPathParam(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.EscapedPath()))
+	}))
+	defer srv.Close()
+	c := New().WithBaseURL(srv.URL)
+
+	got, err := c.Get("/users/{id}/repos/{repo}").
+		WithPathParam("id", "a/../b").
+		WithPathParams(map[string]string{"repo": "x"}).
+		String()
+	if err != nil {
+		t.Fatalf("Request.String() error = %v", err)
+	}
+	if want := "/users/a%2F..%2Fb/repos/x"; got != want {
+		t.Errorf("path sent = %s, want %s", got, want)
+	}
+
+	if err := c.Get("/users/{id}").WithPathParams(nil).Error(); err == nil {
+		t.Error("Request.Error() with a missing path param error = nil")
+	}
+}
+
+func TestClient_Getf_Braces(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer srv.Close()
+
+	// Paths without path params are sent as they are, braces and all
+	for _, arg := range []string{"{foo}", "{"} {
+		got, err := New().WithBaseURL(srv.URL).Getf("/search/%s", arg).String()
+		if err != nil {
+			t.Fatalf("Request.String() error = %v", err)
+		}
+		if want := "/search/" + arg; got != want {
+			t.Errorf("path sent = %s, want %s", got, want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package httpclient
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// pinPrefix is the optional prefix of a pinned key hash
+const pinPrefix = "sha256/"
+
+// PinError is returned when a servers certificate chain doesn't contain any
+// of the public keys pinned for its host
+type PinError struct {
+	Host  string   // The host connected to
+	Pins  []string // The hashes pinned for the host
+	Chain []string // The hashes of the public keys in the servers chain
+}
+
+// Error returns the PinErrors message
+func (e *PinError) Error() string {
+	return fmt.Sprintf("httpclient: certificate chain for %s matches none of its %d pinned keys (chain keys: %s)",
+		e.Host, len(e.Pins), strings.Join(e.Chain, ", "))
+}
+
+// WithPinnedKeys pins the public keys accepted from the passed host. Every
+// TLS connection to it must have at least one certificate in its chain whose
+// SubjectPublicKeyInfo has one of the passed base64 SHA-256 hashes (as
+// returned by PublicKeyPin), so pinning the keys of both the current and
+// next certificate allows them to be rotated. A host like *.example.com pins
+// all subdomains of example.com. Connections failing the check return a
+// *PinError
+func (c *Client) WithPinnedKeys(host string, pins ...string) *Client {
+	hashes := make([]string, 0, len(pins))
+	for _, pin := range pins {
+		pin = strings.TrimPrefix(pin, pinPrefix)
+		if b, err := base64.StdEncoding.DecodeString(pin); err != nil || len(b) != sha256.Size {
+			c.err = fmt.Errorf("httpclient: invalid pinned key %q for %s", pin, host)
+			return c
+		}
+		hashes = append(hashes, pin)
+	}
+	return c.withTLS(func(s *tlsSettings) {
+		s.pins = s.pins.withHost(strings.ToLower(host), hashes)
+	})
+}
+
+// WithPinReportOnly stops pinned keys failing connections, instead writing a
+// warning with the PinError to the passed slog Logger (or the default logger
+// when nil) for every connection that would have failed
+func (c *Client) WithPinReportOnly(logger *slog.Logger) *Client {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return c.withTLS(func(s *tlsSettings) {
+		s.pins = s.pins.withHost("", nil)
+		s.pins.reportOnly = logger
+	})
+}
+
+// PublicKeyPin returns the base64 SHA-256 hash of the certificates
+// SubjectPublicKeyInfo, as passed to WithPinnedKeys(...)
+func PublicKeyPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// pinSet are the public keys pinned for each host
+type pinSet struct {
+	hosts      map[string][]string
+	reportOnly *slog.Logger // Logs failures instead of returning them when set
+}
+
+// withHost returns a copy of the pinSet with the passed pins added for the
+// passed host, or just a copy when the host is empty
+func (p *pinSet) withHost(host string, pins []string) *pinSet {
+	out := &pinSet{hosts: map[string][]string{}}
+	if p != nil {
+		out.reportOnly = p.reportOnly
+		for h, pins := range p.hosts {
+			out.hosts[h] = pins
+		}
+	}
+	if host != "" {
+		out.hosts[host] = append(append([]string(nil), out.hosts[host]...), pins...)
+	}
+	return out
+}
+
+// pinsFor returns the keys pinned for the passed host, preferring an exact
+// match over the closest wildcard
+func (p *pinSet) pinsFor(host string) []string {
+	host = strings.ToLower(host)
+	if pins, ok := p.hosts[host]; ok {
+		return pins
+	}
+	for domain := host; ; {
+		_, parent, ok := strings.Cut(domain, ".")
+		if !ok {
+			return nil
+		}
+		if pins, ok := p.hosts["*."+parent]; ok {
+			return pins
+		}
+		domain = parent
+	}
+}
+
+// verifyConnection is the tls Config VerifyConnection func checking the
+// servers verified certificate chain has a pinned key
+func (p *pinSet) verifyConnection(cs tls.ConnectionState) error {
+	host, pins := cs.ServerName, p.pinsFor(cs.ServerName)
+	if host == "" && len(cs.PeerCertificates) > 0 {
+		// TLS doesn't send IP addresses as the server name, but they've been
+		// verified against those in the leaf certificate
+		for _, ip := range cs.PeerCertificates[0].IPAddresses {
+			if ipPins := p.pinsFor(ip.String()); len(ipPins) > 0 {
+				host, pins = ip.String(), append(pins, ipPins...)
+			}
+		}
+	}
+	if len(pins) == 0 {
+		return nil
+	}
+	// Only the verified chains are checked, as servers can send any other
+	// certificates alongside them. Without verification only the leaf can be
+	// trusted to belong to the server
+	var certs []*x509.Certificate
+	for _, chain := range cs.VerifiedChains {
+		certs = append(certs, chain...)
+	}
+	if len(cs.VerifiedChains) == 0 && len(cs.PeerCertificates) > 0 {
+		certs = cs.PeerCertificates[:1]
+	}
+
+	var chain []string
+	seen := map[string]bool{}
+	for _, cert := range certs {
+		hash := PublicKeyPin(cert)
+		for _, pin := range pins {
+			if hash == pin {
+				return nil
+			}
+		}
+		if !seen[hash] {
+			seen[hash] = true
+			chain = append(chain, hash)
+		}
+	}
+
+	err := &PinError{Host: host, Pins: pins, Chain: chain}
+	if p.reportOnly != nil {
+		p.reportOnly.Warn("pinned key mismatch", slog.String("host", err.Host), slog.Any("error", err))
+		return nil
+	}
+	return err
+}
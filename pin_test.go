@@ -0,0 +1,136 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_WithPinnedKeys(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	leaf := newTestCert(t, "server", ca)
+	other := newTestCert(t, "other", nil)
+	cert, err := tls.X509KeyPair(append(leaf.certPEM, ca.certPEM...), leaf.keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // Quieten rejected handshakes
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	srv.StartTLS()
+	defer srv.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	tests := []struct {
+		name       string
+		host       string
+		pins       []string
+		reportOnly bool
+		wantErr    bool
+		wantLog    bool
+	}{
+		{name: "leaf key", host: "127.0.0.1", pins: []string{PublicKeyPin(leaf.cert)}},
+		{name: "ca key", host: "127.0.0.1", pins: []string{PublicKeyPin(ca.cert)}},
+		{name: "rotation", host: "127.0.0.1", pins: []string{"sha256/" + PublicKeyPin(other.cert), PublicKeyPin(leaf.cert)}},
+		{name: "mismatch", host: "127.0.0.1", pins: []string{PublicKeyPin(other.cert)}, wantErr: true},
+		{name: "report only", host: "127.0.0.1", pins: []string{PublicKeyPin(other.cert)}, reportOnly: true, wantLog: true},
+		{name: "other host", host: "example.com", pins: []string{PublicKeyPin(other.cert)}},
+		{name: "invalid pin", host: "127.0.0.1", pins: []string{"not a hash"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			c := New().WithRootCAs(pool).WithPinnedKeys(tt.host, tt.pins...)
+			if tt.reportOnly {
+				c.WithPinReportOnly(slog.New(slog.NewTextHandler(&logs, nil)))
+			}
+			err := c.WithBaseURL(srv.URL).Get("/").Error()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Request.Error() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := strings.Contains(logs.String(), "pinned key mismatch"); got != tt.wantLog {
+				t.Errorf("logged mismatch = %v, want %v: %s", got, tt.wantLog, logs.String())
+			}
+		})
+	}
+
+	t.Run("PinError", func(t *testing.T) {
+		err := New().WithRootCAs(pool).
+			WithPinnedKeys("127.0.0.1", PublicKeyPin(other.cert)).
+			WithBaseURL(srv.URL).
+			Get("/").
+			Error()
+		var pinErr *PinError
+		if !errors.As(err, &pinErr) {
+			t.Fatalf("Request.Error() error = %v, want a *PinError", err)
+		}
+		want := []string{PublicKeyPin(leaf.cert), PublicKeyPin(ca.cert)}
+		if pinErr.Host != "127.0.0.1" || strings.Join(pinErr.Chain, ",") != strings.Join(want, ",") {
+			t.Errorf("PinError = %+v, want host 127.0.0.1 and chain %v", pinErr, want)
+		}
+	})
+}
+
+func TestClient_WithPinnedKeys_UnverifiedCertificate(t *testing.T) {
+	// The server sends a pinned certificate it doesn't own after its real
+	// chain, which mustn't satisfy the pin
+	ca := newTestCert(t, "ca", nil)
+	leaf := newTestCert(t, "server", ca)
+	pinned := newTestCert(t, "pinned", nil)
+	cert, err := tls.X509KeyPair(append(leaf.certPEM, pinned.certPEM...), leaf.keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // Quieten rejected handshakes
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	srv.StartTLS()
+	defer srv.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	err = New().WithRootCAs(pool).
+		WithPinnedKeys("127.0.0.1", PublicKeyPin(pinned.cert)).
+		WithBaseURL(srv.URL).
+		Get("/").
+		Error()
+	var pinErr *PinError
+	if !errors.As(err, &pinErr) {
+		t.Fatalf("Request.Error() error = %v, want a *PinError", err)
+	}
+}
+
+func TestPinSet_PinsFor(t *testing.T) {
+	pins := (*pinSet)(nil).
+		withHost("api.example.com", []string{"exact"}).
+		withHost("*.example.com", []string{"wildcard"})
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"api.example.com", "exact"},
+		{"API.example.com", "exact"},
+		{"www.example.com", "wildcard"},
+		{"a.b.example.com", "wildcard"},
+		{"example.com", ""},
+		{"example.org", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := strings.Join(pins.pinsFor(tt.host), ","); got != tt.want {
+				t.Errorf("pinsFor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
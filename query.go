@@ -0,0 +1,238 @@
+package httpclient
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WithQuery adds a query parameter to the Request, alongside any already in
+// its base URL or path
+func (r *Request) WithQuery(key, value string) *Request {
+	if r.query == nil {
+		r.query = url.Values{}
+	}
+	r.query.Add(key, value)
+	return r
+}
+
+// WithQueryValues adds the passed query parameters to the Request
+func (r *Request) WithQueryValues(values url.Values) *Request {
+	for key, vs := range values {
+		for _, v := range vs {
+			r.WithQuery(key, v)
+		}
+	}
+	return r
+}
+
+// WithQueryStruct adds the fields of the passed struct, or pointer to one,
+// as query parameters of the Request. Fields are named by their url tag,
+// like `url:"name,omitempty"`, and skipped with `url:"-"`. The options are
+//
+//	omitempty  skip the field when it has its zero value
+//	comma      send slices as a single comma separated value
+//	brackets   send slices as repeated name[] parameters
+//	unix       send times as Unix seconds
+//
+// Slices are otherwise sent as repeated parameters. Times are formatted with
+// the layout in a `layout:"..."` tag, or RFC 3339 by default. The fields of
+// embedded structs are added as if they were fields of the outer struct
+func (r *Request) WithQueryStruct(v interface{}) *Request {
+	values, err := encodeQueryStruct(v)
+	if err != nil {
+		r.err = err
+		return r
+	}
+	return r.WithQueryValues(values)
+}
+
+// applyQuery adds the Requests query parameters to the passed URL, after
+// those from the base URL and path
+func (r *Request) applyQuery(u *url.URL) {
+	if len(r.query) == 0 {
+		return
+	}
+	q := r.query.Encode()
+	if u.RawQuery != "" {
+		q = u.RawQuery + "&" + q
+	}
+	u.RawQuery = q
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// encodeQueryStruct returns the query parameters for the fields of the
+// passed struct
+func encodeQueryStruct(v interface{}) (url.Values, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return url.Values{}, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("httpclient: query struct is a %s, not a struct", rv.Kind())
+	}
+	values := url.Values{}
+	if err := encodeQueryFields(values, rv); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// encodeQueryFields adds the fields of the passed struct value to the passed
+// query parameters
+func encodeQueryFields(values url.Values, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag := field.Tag.Get("url")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := rv.Field(i)
+
+		// Flatten embedded structs without a name of their own
+		if field.Anonymous && name == "" {
+			if ev := indirect(fv); ev.Kind() == reflect.Struct && ev.Type() != timeType {
+				if err := encodeQueryFields(values, ev); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		options := queryOptions(opts)
+		if options["omitempty"] && isEmptyValue(fv) {
+			continue
+		}
+		if fv = indirect(fv); !fv.IsValid() {
+			continue
+		}
+
+		if (fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array) && !fv.Type().Implements(textMarshalerType) &&
+			fv.Type().Elem().Kind() != reflect.Uint8 {
+			var items []string
+			for j := 0; j < fv.Len(); j++ {
+				s, err := queryValue(fv.Index(j), field, options)
+				if err != nil {
+					return err
+				}
+				items = append(items, s)
+			}
+			switch {
+			case options["comma"]:
+				values.Add(name, strings.Join(items, ","))
+			case options["brackets"]:
+				values[name+"[]"] = append(values[name+"[]"], items...)
+			default:
+				values[name] = append(values[name], items...)
+			}
+			continue
+		}
+
+		s, err := queryValue(fv, field, options)
+		if err != nil {
+			return err
+		}
+		values.Add(name, s)
+	}
+	return nil
+}
+
+// queryValue formats a single value of the passed field
+func queryValue(v reflect.Value, field reflect.StructField, options map[string]bool) (string, error) {
+	if v = indirect(v); !v.IsValid() {
+		return "", nil
+	}
+	if !v.CanInterface() && (v.Type() == timeType || reflect.PointerTo(v.Type()).Implements(textMarshalerType)) {
+		return "", fmt.Errorf("httpclient: query field %s can't be read through an unexported embedded struct", field.Name)
+	}
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if options["unix"] {
+			return strconv.FormatInt(t.Unix(), 10), nil
+		}
+		if layout := field.Tag.Get("layout"); layout != "" {
+			return t.Format(layout), nil
+		}
+		return t.Format(time.RFC3339), nil
+	}
+	if v.Type().Implements(textMarshalerType) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textMarshalerType) {
+		b, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), nil
+		}
+	}
+	return "", fmt.Errorf("httpclient: unsupported query field %s of type %s", field.Name, v.Type())
+}
+
+// indirect returns the value the passed pointer or interface value points
+// to, or the zero Value if it's nil
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// queryOptions returns the comma separated options of a url tag as a set
+func queryOptions(opts string) map[string]bool {
+	options := map[string]bool{}
+	for _, o := range strings.Split(opts, ",") {
+		if o != "" {
+			options[o] = true
+		}
+	}
+	return options
+}
+
+// isEmptyValue reports whether the passed value is omitted by omitempty
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	}
+	if v.Type() == timeType && v.CanInterface() {
+		return v.Interface().(time.Time).IsZero()
+	}
+	return v.IsZero()
+}
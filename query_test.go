@@ -0,0 +1,146 @@
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type testPage struct {
+	Page    int `url:"page,omitempty"`
+	PerPage int `url:"per_page,omitempty"`
+}
+
+type testSearch struct {
+	testPage
+	Query   string    `url:"q"`
+	Tags    []string  `url:"tag"`
+	IDs     []int     `url:"ids,comma,omitempty"`
+	Kinds   []string  `url:"kind,brackets,omitempty"`
+	Since   time.Time `url:"since,omitempty"`
+	Until   time.Time `url:"until,unix,omitempty"`
+	Day     time.Time `url:"day,omitempty" layout:"2006-01-02"`
+	Draft   *bool     `url:"draft,omitempty"`
+	Score   float64   `url:"score,omitempty"`
+	IP      net.IP    `url:"ip,omitempty"`
+	Secret  string    `url:"-"`
+	Default string
+	private string
+}
+
+func TestEncodeQueryStruct(t *testing.T) {
+	draft := false
+	when := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	tests := []struct {
+		name    string
+		v       interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "zero",
+			v:    testSearch{},
+			want: "Default=&q=",
+		},
+		{
+			name: "all fields",
+			v: &testSearch{
+				testPage: testPage{Page: 2, PerPage: 50},
+				Query:    "go http",
+				Tags:     []string{"a", "b"},
+				IDs:      []int{1, 2, 3},
+				Kinds:    []string{"x", "y"},
+				Since:    when,
+				Until:    when,
+				Day:      when,
+				Draft:    &draft,
+				Score:    1.5,
+				IP:       net.ParseIP("10.0.0.1"),
+				Secret:   "s",
+				Default:  "d",
+				private:  "p",
+			},
+			want: "Default=d&day=2024-05-06&draft=false&ids=1%2C2%2C3&ip=10.0.0.1&kind%5B%5D=x&kind%5B%5D=y" +
+				"&page=2&per_page=50&q=go+http&score=1.5&since=2024-05-06T07%3A08%3A09Z&tag=a&tag=b&until=1714979289",
+		},
+		{name: "nil pointer", v: (*testSearch)(nil)},
+		{name: "not a struct", v: "q=1", wantErr: true},
+		{name: "unsupported field", v: struct{ M map[string]int }{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := encodeQueryStruct(tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("encodeQueryStruct() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Encode() != tt.want {
+				t.Errorf("encodeQueryStruct() = %s, want %s", got.Encode(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRequest_WithQuery(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.RawQuery))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		base    string
+		path    string
+		request func(r *Request) *Request
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "no query",
+			path:    "/search",
+			request: func(r *Request) *Request { return r },
+		},
+		{
+			name:    "single",
+			path:    "/search",
+			request: func(r *Request) *Request { return r.WithQuery("q", "a&b") },
+			want:    "q=a%26b",
+		},
+		{
+			name: "merged with the path query",
+			path: "/search?sort=asc",
+			request: func(r *Request) *Request {
+				return r.WithQuery("q", "x").
+					WithQueryValues(url.Values{"tag": {"a", "b"}}).
+					WithQueryStruct(testPage{Page: 3})
+			},
+			want: "sort=asc&page=3&q=x&tag=a&tag=b",
+		},
+		{
+			name:    "merged with the base URL query",
+			base:    "?key=k",
+			path:    "/search?sort=asc",
+			request: func(r *Request) *Request { return r.WithQuery("q", "x") },
+			want:    "key=k&sort=asc&q=x",
+		},
+		{
+			name:    "invalid struct",
+			path:    "/search",
+			request: func(r *Request) *Request { return r.WithQueryStruct(1) },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.request(New().WithBaseURL(srv.URL + tt.base).Get(tt.path)).String()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Request.String() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("query sent = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
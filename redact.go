@@ -0,0 +1,69 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+)
+
+// Redacted is the value that replaces secrets in logs and debug output
+const Redacted = "[REDACTED]"
+
+// SensitiveHeaders are the headers that are always redacted from logs and
+// debug output
+var SensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// RedactHeader returns a copy of the passed http Header with the values of
+// all SensitiveHeaders and the passed extra headers replaced
+func RedactHeader(h http.Header, extra ...string) http.Header {
+	out := h.Clone()
+	for _, list := range [][]string{SensitiveHeaders, extra} {
+		for _, key := range list {
+			key = http.CanonicalHeaderKey(key)
+			if vs, ok := out[key]; ok {
+				for i := range vs {
+					vs[i] = Redacted
+				}
+			}
+		}
+	}
+	return out
+}
+
+// RedactRequest returns copies of the http Header and URL of the passed http
+// Request with the values of all SensitiveHeaders, the passed extra headers
+// and any credentials set with WithBasicAuth(...), WithBearerToken(...) or
+// WithAPIKey(...) replaced
+func RedactRequest(req *http.Request, extra ...string) (http.Header, *url.URL) {
+	h, u := redactCredentials(req.Header, req.URL, credentialsFromRequest(req))
+	return RedactHeader(h, extra...), u
+}
+
+// RedactCredentials returns copies of the http Header and URL of the passed
+// http Request with only the values of any credentials set with
+// WithBasicAuth(...), WithBearerToken(...), WithAPIKey(...), a TokenSource or
+// Digest authentication replaced
+func RedactCredentials(req *http.Request) (http.Header, *url.URL) {
+	return redactCredentials(req.Header, req.URL, credentialsFromRequest(req))
+}
+
+// jsonRedactor returns a function that replaces the values of the passed
+// JSON object fields, wherever they appear, in a (possibly truncated) JSON
+// document
+func jsonRedactor(fields []string) func([]byte) []byte {
+	res := make([]*regexp.Regexp, len(fields))
+	for i, field := range fields {
+		res[i] = regexp.MustCompile(`("` + regexp.QuoteMeta(field) + `"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+	}
+	return func(b []byte) []byte {
+		for _, re := range res {
+			b = re.ReplaceAll(b, []byte(`${1}"`+Redacted+`"`))
+		}
+		return b
+	}
+}
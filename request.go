@@ -5,10 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"encoding/xml"
-	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/cenkalti/backoff/v4"
 )
@@ -20,17 +20,51 @@ type Request struct {
 	client         *http.Client // DO NOT MODIFY THIS CLIENT
 	method         string
 	baseURL        string
+	urlJoin        URLJoinMode
 	path           string
+	pathParams     map[string]string
+	query          url.Values
 	headers        []header
 	expectedStatus int // The statusCode that is expected for a success
 	retryCount     int // Number of times to retry
-	body           io.ReadWriter
+	retryPolicy    RetryPolicy
+	maxRetryAfter  time.Duration
+	backoff        backoff.BackOff
+	clock          Clock
+	budget         *RetryBudget
+	middleware     []Middleware
+	credentials    []credential
+	tokens         *tokenCache
+	digest         *digestAuth
+	body           io.Reader
+	streamBody     bool  // Whether the body is a stream that can't be replayed
+	bodyLimit      int64 // Max bytes of a non-seekable body buffered for replay
 	ctx            context.Context
 }
 
-// WithBody sets the body on the request with the passed io.ReadWriter
+// WithBody sets the body on the request with the passed io.ReadWriter. Bodies
+// that can't be seeked are buffered (up to the limit set with
+// WithBodyBufferLimit(...)) so they can be resent on retries and redirects
 func (r *Request) WithBody(body io.ReadWriter) *Request {
 	r.body = body
+	r.streamBody = false
+	return r
+}
+
+// WithStream sets the passed io.Reader as the body to be used on the Request
+// without buffering it. As the body can only be read once the Request will
+// never be retried and redirects requiring the body to be resent will fail
+func (r *Request) WithStream(body io.Reader) *Request {
+	r.body = body
+	r.streamBody = true
+	return r
+}
+
+// WithBodyBufferLimit sets the maximum number of bytes of a non-seekable body
+// that will be buffered so it can be resent. Bodies larger than the limit are
+// streamed and the Request will not be retried
+func (r *Request) WithBodyBufferLimit(limit int64) *Request {
+	r.bodyLimit = limit
 	return r
 }
 
@@ -54,17 +88,17 @@ func (r *Request) WithForm(data url.Values) *Request {
 // WithJSON sets the JSON encoded passed interface as the body to be used on
 // the Request
 func (r *Request) WithJSON(body interface{}) *Request {
-	r.body = bytes.NewBuffer(nil)
-	r.err = json.NewEncoder(r.body).Encode(body)
-	return r.WithContentType("application/json")
+	buf := bytes.NewBuffer(nil)
+	r.err = json.NewEncoder(buf).Encode(body)
+	return r.WithBody(buf).WithContentType("application/json")
 }
 
 // WithXML sets the XML encoded passed interface as the body to be used on the
 // Request
 func (r *Request) WithXML(body interface{}) *Request {
-	r.body = bytes.NewBuffer(nil)
-	r.err = xml.NewEncoder(r.body).Encode(body)
-	return r.WithContentType("application/xml")
+	buf := bytes.NewBuffer(nil)
+	r.err = xml.NewEncoder(buf).Encode(body)
+	return r.WithBody(buf).WithContentType("application/xml")
 }
 
 // WithContext sets the context on the Request
@@ -94,13 +128,44 @@ func (r *Request) WithExpectedStatus(expectedStatusCode int) *Request {
 }
 
 // WithRetry sets the desired number of retries on the Request
-// Note: In order to trigger retries you must set an expected status code with
-// the WithExpectedStatus(...) method
+// Note: Unless a RetryPolicy is set with WithRetryPolicy(...), retries are
+// only triggered by setting an expected status code with the
+// WithExpectedStatus(...) method
 func (r *Request) WithRetry(retryCount int) *Request {
 	r.retryCount = retryCount
 	return r
 }
 
+// WithRetryPolicy sets the RetryPolicy that decides whether a failed attempt
+// should be retried, overriding any policy set on the Client
+func (r *Request) WithRetryPolicy(policy RetryPolicy) *Request {
+	r.retryPolicy = policy
+	return r
+}
+
+// WithMaxRetryAfter sets the longest the Request will wait between retries
+// when a server asks it to with a Retry-After or rate-limit reset header,
+// overriding any maximum set on the Client. When asked to wait any longer the
+// Request stops retrying
+func (r *Request) WithMaxRetryAfter(max time.Duration) *Request {
+	r.maxRetryAfter = max
+	return r
+}
+
+// WithBackoff sets the backoff.BackOff used to decide how long to wait between
+// retries, overriding any set on the Client
+func (r *Request) WithBackoff(b backoff.BackOff) *Request {
+	r.backoff = b
+	return r
+}
+
+// WithClock sets the Clock used to time retries, overriding any set on the
+// Client
+func (r *Request) WithClock(clock Clock) *Request {
+	r.clock = clock
+	return r
+}
+
 // String is a convenience method that handles executing, defer closing, and
 // decoding the body into a string before returning
 func (r *Request) String() (string, error) {
@@ -120,7 +185,7 @@ func (r *Request) Bytes() ([]byte, error) {
 	}
 	defer res.Close()
 	if r.expectedStatus > 0 && res.StatusCode() != r.expectedStatus {
-		return nil, fmt.Errorf("Unexpected status received : %s", res.Status())
+		return nil, res.statusError()
 	}
 	return res.Bytes()
 }
@@ -134,7 +199,7 @@ func (r *Request) JSON(out interface{}) error {
 	}
 	defer res.Close()
 	if r.expectedStatus > 0 && res.StatusCode() != r.expectedStatus {
-		return fmt.Errorf("Unexpected status received : %s", res.Status())
+		return res.statusError()
 	}
 	return res.JSON(out)
 }
@@ -164,7 +229,7 @@ func (r *Request) XML(out interface{}) error {
 	}
 	defer res.Close()
 	if r.expectedStatus > 0 && res.StatusCode() != r.expectedStatus {
-		return fmt.Errorf("Unexpected status received : %s", res.Status())
+		return res.statusError()
 	}
 	return res.XML(out)
 }
@@ -193,7 +258,7 @@ func (r *Request) Error() error {
 	}
 	defer res.Close()
 	if r.expectedStatus > 0 && res.StatusCode() != r.expectedStatus {
-		return fmt.Errorf("Unexpected status received : %s", res.Status())
+		return res.statusError()
 	}
 	return nil
 }
@@ -213,22 +278,38 @@ func (r *Request) Do() (*Response, error) {
 	}
 
 	// Perform the request with retries, returning the wrapped http.Response
-	res, err := doRetry(r.client, req, r.expectedStatus, r.retryCount)
-	if err != nil {
-		return nil, err
-	}
-	return &Response{res: res}, nil
+	return r.doRetry(req)
 }
 
 // toHTTPRequest converts a Request to a standard HTTP Request. It assumes
 // there is no error on the request.
 func (r *Request) toHTTPRequest() (*http.Request, error) {
+	// Expand any path params into the Requests path
+	path, err := expandPath(r.path, r.pathParams)
+	if err != nil {
+		return nil, err
+	}
+
+	// Join the path onto the base URL
+	u, err := joinURL(r.baseURL, path, r.urlJoin)
+	if err != nil {
+		return nil, err
+	}
+
 	// Generate a new http Request using client and passed Request
-	req, err := http.NewRequest(r.method, r.baseURL+r.path, r.body)
+	req, err := http.NewRequest(r.method, u.String(), nil)
 	if err != nil {
 		return nil, err
 	}
 
+	// Add any query parameters set on the Request
+	r.applyQuery(req.URL)
+
+	// Set the body in a form that can be replayed where possible
+	if err := r.setBody(req); err != nil {
+		return nil, err
+	}
+
 	// Apply a context if one is set on the Request
 	if r.ctx != nil {
 		req = req.WithContext(r.ctx)
@@ -238,44 +319,7 @@ func (r *Request) toHTTPRequest() (*http.Request, error) {
 	for _, h := range r.headers {
 		req.Header.Set(h.key, h.value)
 	}
-	return req, nil
-}
 
-// doRetry executes the passed http Request using the passed http Client and
-// retries as many times as specified
-func doRetry(c *http.Client, r *http.Request, expectedStatus, retryCount int) (*http.Response, error) {
-	// Create a ticker that will execute the exponential backoff algorithm
-	ticker := backoff.NewTicker(backoff.NewExponentialBackOff())
-
-	// Define the return variables
-	var res *http.Response
-	var err error
-
-	// Continuously retry HTTP requests
-	tries := 0
-	for range ticker.C {
-		tries++ // Increment the tries value to indicate which try num we're on
-
-		// Perform the request using the standard library
-		res, err = c.Do(r)
-		if err != nil {
-			return nil, err
-		}
-
-		// If the status code isn't what we expect
-		if expectedStatus > 0 && expectedStatus != res.StatusCode {
-			if retryCount > tries {
-				continue // Retry if we should
-			}
-			err = fmt.Errorf("request failed to get expected status after %v retries", retryCount)
-		}
-
-		// Stop the ticker and break out of the tick loop
-		ticker.Stop()
-		break
-	}
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	// Apply credentials last so they take precedence over plain headers
+	return r.applyCredentials(req), nil
 }
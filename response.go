@@ -6,11 +6,17 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"time"
 )
 
 // Response contains the raw http.Response reference OR any error that took
 // place while performing the request
-type Response struct{ res *http.Response }
+type Response struct {
+	res      *http.Response
+	attempts int
+	elapsed  time.Duration
+	timing   Timing
+}
 
 // Body returns the io Readcloser body on the Responses http Response
 func (r *Response) Body() io.ReadCloser { return r.res.Body }
@@ -35,6 +41,11 @@ func (r *Response) Status() string { return r.res.Status }
 // StatusCode returns the status code found on the Response
 func (r *Response) StatusCode() int { return r.res.StatusCode }
 
+// statusError returns a StatusError describing the Response
+func (r *Response) statusError() *StatusError {
+	return newStatusError(r.res, r.attempts, r.elapsed)
+}
+
 // String attempts to return the decoded response as a string
 func (r *Response) String() (string, error) {
 	bytes, err := r.Bytes()
@@ -0,0 +1,280 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// DefaultMaxRetryAfter is the longest a Request will wait between retries
+// when a server asks it to with a Retry-After or rate-limit reset header,
+// unless another maximum has been set with WithMaxRetryAfter(...). Requests
+// asked to wait any longer stop retrying and return the response
+var DefaultMaxRetryAfter = 2 * time.Minute
+
+// RetryPolicy decides whether a Request should be retried. It is given the
+// number of the attempt that just completed (starting at 1) along with the
+// http Response and error that attempt produced, one of which may be nil
+type RetryPolicy interface {
+	Retry(attempt int, res *http.Response, err error) bool
+}
+
+// RetryPolicyFunc is an adapter that allows the use of an ordinary function
+// as a RetryPolicy
+type RetryPolicyFunc func(attempt int, res *http.Response, err error) bool
+
+// Retry calls f(attempt, res, err)
+func (f RetryPolicyFunc) Retry(attempt int, res *http.Response, err error) bool {
+	return f(attempt, res, err)
+}
+
+var (
+	// RetryOnServerError retries any attempt that received a 5xx status
+	RetryOnServerError RetryPolicy = RetryPolicyFunc(func(_ int, res *http.Response, err error) bool {
+		return err == nil && res.StatusCode >= 500 && res.StatusCode <= 599
+	})
+
+	// RetryOnTooManyRequests retries any attempt that received a 429 status
+	RetryOnTooManyRequests RetryPolicy = RetryPolicyFunc(func(_ int, res *http.Response, err error) bool {
+		return err == nil && res.StatusCode == http.StatusTooManyRequests
+	})
+
+	// RetryOnConnectionReset retries any attempt whose connection was reset
+	// or closed by the remote before a response was received
+	RetryOnConnectionReset RetryPolicy = RetryPolicyFunc(func(_ int, _ *http.Response, err error) bool {
+		return err != nil && (errors.Is(err, syscall.ECONNRESET) ||
+			errors.Is(err, syscall.ECONNABORTED) ||
+			errors.Is(err, syscall.EPIPE) ||
+			errors.Is(err, io.EOF) ||
+			errors.Is(err, io.ErrUnexpectedEOF))
+	})
+
+	// RetryOnDialError retries any attempt that failed to establish a
+	// connection with the remote, such as a refused connection
+	RetryOnDialError RetryPolicy = RetryPolicyFunc(func(_ int, _ *http.Response, err error) bool {
+		var opErr *net.OpError
+		return errors.As(err, &opErr) && opErr.Op == "dial"
+	})
+
+	// RetryOnTimeout retries any attempt that timed out
+	RetryOnTimeout RetryPolicy = RetryPolicyFunc(func(_ int, _ *http.Response, err error) bool {
+		var netErr net.Error
+		return errors.Is(err, os.ErrDeadlineExceeded) ||
+			(errors.As(err, &netErr) && netErr.Timeout())
+	})
+)
+
+// RetryAny returns a RetryPolicy that retries if any of the passed policies
+// would retry
+func RetryAny(policies ...RetryPolicy) RetryPolicy {
+	return RetryPolicyFunc(func(attempt int, res *http.Response, err error) bool {
+		for _, p := range policies {
+			if p.Retry(attempt, res, err) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// retryOnUnexpectedStatus returns the default RetryPolicy which retries any
+// attempt that didn't receive the expected status
+func retryOnUnexpectedStatus(expectedStatus int) RetryPolicy {
+	return RetryPolicyFunc(func(_ int, res *http.Response, err error) bool {
+		return err == nil && expectedStatus > 0 && expectedStatus != res.StatusCode
+	})
+}
+
+// doRetry executes the passed http Request using the Requests http Client and
+// retries as many times as specified for as long as the RetryPolicy allows
+func (r *Request) doRetry(req *http.Request) (*Response, error) {
+	policy := r.retryPolicy
+	if policy == nil {
+		policy = retryOnUnexpectedStatus(r.expectedStatus)
+	}
+	maxRetryAfter := r.maxRetryAfter
+	if maxRetryAfter <= 0 {
+		maxRetryAfter = DefaultMaxRetryAfter
+	}
+
+	clock := r.clock
+	if clock == nil {
+		clock = SystemClock
+	}
+	start, realStart := clock.Now(), time.Now()
+
+	// Create the backoff algorithm used between attempts
+	b := newBackoff(r.backoff, clock)
+	ctx := req.Context()
+	handler := r.handler()
+
+	// Record the request against the retry budget
+	if r.budget != nil {
+		r.budget.deposit(start)
+	}
+
+	// Define the return variables
+	var res *http.Response
+	var err error
+	var budgetExhausted bool
+	var timings []Timing
+
+	// Continuously retry HTTP requests
+	tries := 1
+	for ; ; tries++ {
+		// Rewind the body of the request if it's already been sent
+		if tries > 1 {
+			if req, err = rewind(req); err != nil {
+				break
+			}
+		}
+
+		// Perform the request through the middleware chain
+		var wrapped *Response
+		t := newTracer()
+		attempt := req.WithContext(withTracer(context.WithValue(ctx, attemptKey{}, tries), t))
+		if wrapped, err = handler(attempt); wrapped != nil {
+			res = wrapped.res
+		}
+		timings = append(timings, t.done())
+
+		// Stop if we're out of tries, the Request has been cancelled, the
+		// body can't be resent or the policy doesn't want us to retry
+		if r.retryCount <= tries || ctx.Err() != nil || !replayable(req) ||
+			!policy.Retry(tries, res, err) {
+			break
+		}
+
+		// Wait for as long as the server asked, falling back to the backoff
+		wait := b.NextBackOff()
+		if wait == backoff.Stop {
+			break
+		}
+
+		// Give up rather than retry early when the server asks us to wait
+		// longer than the maximum
+		if d, ok := retryAfter(res, clock.Now()); ok {
+			if d > maxRetryAfter {
+				break
+			}
+			wait = d
+		}
+
+		// Give up immediately once the Clients retry budget has been spent
+		if r.budget != nil && !r.budget.withdraw(clock.Now()) {
+			budgetExhausted = true
+			break
+		}
+
+		// Don't bother waiting if the next attempt can't finish in time
+		if deadline, ok := ctx.Deadline(); ok && clock.Now().Add(wait).After(deadline) {
+			break
+		}
+		discard(res)
+		res = nil
+		if err = clock.Sleep(ctx, wait); err != nil {
+			break
+		}
+	}
+	elapsed := clock.Now().Sub(start)
+
+	// Fail with a StatusError if the status code still isn't what we expect
+	// or we gave up retrying it
+	if err == nil && (budgetExhausted ||
+		(r.expectedStatus > 0 && r.expectedStatus != res.StatusCode)) {
+		err = newStatusError(res, tries, elapsed)
+	}
+	if err != nil {
+		discard(res)
+		if tries > 1 || budgetExhausted {
+			err = &RetryError{
+				Attempts:        tries,
+				Elapsed:         elapsed,
+				BudgetExhausted: budgetExhausted,
+				Err:             err,
+			}
+		}
+		return nil, err
+	}
+	timing := timings[len(timings)-1]
+	timing.Total = time.Since(realStart)
+	timing.Attempts = timings
+	return &Response{res: res, attempts: tries, elapsed: elapsed, timing: timing}, nil
+}
+
+// retryAfter returns how long the server asked us to wait before retrying
+// the passed http Response, as found in the Retry-After header or one of the
+// common rate-limit reset headers
+func retryAfter(res *http.Response, now time.Time) (time.Duration, bool) {
+	if res == nil {
+		return 0, false
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+	default:
+		return 0, false
+	}
+
+	// Retry-After is either a number of seconds or an HTTP-date
+	if v := strings.TrimSpace(res.Header.Get("Retry-After")); v != "" {
+		if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return clampWait(time.Duration(secs) * time.Second), true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return clampWait(t.Sub(now)), true
+		}
+	}
+
+	// The rate-limit reset headers are only meaningful when rate-limited
+	if res.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	for _, key := range []string{"RateLimit-Reset", "X-RateLimit-Reset", "X-Rate-Limit-Reset"} {
+		v := strings.TrimSpace(res.Header.Get(key))
+		if v == "" {
+			continue
+		}
+		secs, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			continue
+		}
+		// Large values are a unix timestamp rather than a number of seconds
+		if secs > unixResetThreshold {
+			return clampWait(time.Unix(0, int64(secs*float64(time.Second))).Sub(now)), true
+		}
+		return clampWait(time.Duration(secs * float64(time.Second))), true
+	}
+	return 0, false
+}
+
+// unixResetThreshold is the value above which a rate-limit reset header is
+// treated as a unix timestamp (roughly 2001-09-09) instead of delta-seconds
+const unixResetThreshold = 1e9
+
+// clampWait ensures a wait is never negative
+func clampWait(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// discard drains and closes the body of the passed http Response so that the
+// underlying connection may be reused
+func discard(res *http.Response) {
+	if res == nil {
+		return
+	}
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+}
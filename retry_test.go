@@ -0,0 +1,284 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicies(t *testing.T) {
+	timeoutErr := &url.Error{Op: "Get", URL: "http://example.com", Err: &net.OpError{Op: "read", Err: timeoutError{}}}
+	dialErr := &url.Error{Op: "Get", URL: "http://example.com", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}
+	resetErr := &url.Error{Op: "Get", URL: "http://example.com", Err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}}
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		res    *http.Response
+		err    error
+		want   bool
+	}{
+		{name: "server error 503", policy: RetryOnServerError, res: &http.Response{StatusCode: 503}, want: true},
+		{name: "server error 404", policy: RetryOnServerError, res: &http.Response{StatusCode: 404}},
+		{name: "server error transport error", policy: RetryOnServerError, err: resetErr},
+		{name: "too many requests 429", policy: RetryOnTooManyRequests, res: &http.Response{StatusCode: 429}, want: true},
+		{name: "too many requests 500", policy: RetryOnTooManyRequests, res: &http.Response{StatusCode: 500}},
+		{name: "connection reset", policy: RetryOnConnectionReset, err: resetErr, want: true},
+		{name: "connection reset eof", policy: RetryOnConnectionReset, err: io.EOF, want: true},
+		{name: "connection reset other", policy: RetryOnConnectionReset, err: errors.New("boom")},
+		{name: "dial error", policy: RetryOnDialError, err: dialErr, want: true},
+		{name: "dial error reset", policy: RetryOnDialError, err: resetErr},
+		{name: "timeout", policy: RetryOnTimeout, err: timeoutErr, want: true},
+		{name: "timeout reset", policy: RetryOnTimeout, err: resetErr},
+		{name: "any", policy: RetryAny(RetryOnDialError, RetryOnServerError), res: &http.Response{StatusCode: 502}, want: true},
+		{name: "any none", policy: RetryAny(RetryOnDialError, RetryOnServerError), res: &http.Response{StatusCode: 200}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Retry(1, tt.res, tt.err); got != tt.want {
+				t.Errorf("RetryPolicy.Retry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequest_WithRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		policy     RetryPolicy
+		retry      int
+		wantStatus int
+		wantCalls  int
+	}{
+		{
+			name:       "retries server errors",
+			statuses:   []int{503, 200},
+			policy:     RetryOnServerError,
+			retry:      3,
+			wantStatus: 200,
+			wantCalls:  2,
+		},
+		{
+			name:       "does not retry client errors",
+			statuses:   []int{404, 200},
+			policy:     RetryOnServerError,
+			retry:      3,
+			wantStatus: 404,
+			wantCalls:  1,
+		},
+		{
+			name:       "stops after retry count",
+			statuses:   []int{500, 500, 200},
+			policy:     RetryOnServerError,
+			retry:      2,
+			wantStatus: 500,
+			wantCalls:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statuses[calls])
+				calls++
+			}))
+			defer srv.Close()
+
+			res, err := New().WithBaseURL(srv.URL).
+				WithClock(&fakeClock{}).
+				WithRetryPolicy(tt.policy).
+				Get("/").
+				WithRetry(tt.retry).
+				Do()
+			if err != nil {
+				t.Fatalf("Request.Do() error = %v", err)
+			}
+			defer res.Close()
+			if res.StatusCode() != tt.wantStatus {
+				t.Errorf("Request.Do() status = %v, want %v", res.StatusCode(), tt.wantStatus)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Request.Do() calls = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRequest_WithRetryPolicy_DialError(t *testing.T) {
+	calls := 0
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return nil, &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}
+		}
+		return &http.Response{StatusCode: 200, Body: http.NoBody, Request: r}, nil
+	})
+	res, err := New().WithTransport(transport).
+		WithClock(&fakeClock{}).
+		Get("http://example.com").
+		WithRetry(2).
+		WithRetryPolicy(RetryOnDialError).
+		Do()
+	if err != nil {
+		t.Fatalf("Request.Do() error = %v", err)
+	}
+	defer res.Close()
+	if calls != 2 {
+		t.Errorf("Request.Do() calls = %v, want 2", calls)
+	}
+}
+
+// roundTripperFunc is an adapter that allows the use of an ordinary function
+// as an http RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// timeoutError is a net.Error that reports a timeout
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		want   time.Duration
+		wantOK bool
+	}{
+		{
+			name:   "delta seconds",
+			status: 429,
+			header: http.Header{"Retry-After": {"120"}},
+			want:   2 * time.Minute,
+			wantOK: true,
+		},
+		{
+			name:   "http date",
+			status: 503,
+			header: http.Header{"Retry-After": {"Sat, 01 Jan 2022 00:00:30 GMT"}},
+			want:   30 * time.Second,
+			wantOK: true,
+		},
+		{
+			name:   "http date in the past",
+			status: 503,
+			header: http.Header{"Retry-After": {"Fri, 31 Dec 2021 23:00:00 GMT"}},
+			want:   0,
+			wantOK: true,
+		},
+		{
+			name:   "ignored on other statuses",
+			status: 500,
+			header: http.Header{"Retry-After": {"120"}},
+		},
+		{
+			name:   "ratelimit reset delta",
+			status: 429,
+			header: http.Header{"Ratelimit-Reset": {"5"}},
+			want:   5 * time.Second,
+			wantOK: true,
+		},
+		{
+			name:   "x-ratelimit-reset unix timestamp",
+			status: 429,
+			header: http.Header{"X-Ratelimit-Reset": {strconv.FormatInt(now.Add(time.Minute).Unix(), 10)}},
+			want:   time.Minute,
+			wantOK: true,
+		},
+		{
+			name:   "ratelimit reset ignored on 503",
+			status: 503,
+			header: http.Header{"X-Ratelimit-Reset": {"5"}},
+		},
+		{
+			name:   "no headers",
+			status: 429,
+			header: http.Header{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &http.Response{StatusCode: tt.status, Header: tt.header}
+			got, ok := retryAfter(res, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("retryAfter() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRequest_WithMaxRetryAfter(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	// Waiting less than the server asked could get us banned, so give up
+	clock := &fakeClock{}
+	err := New().WithBaseURL(srv.URL).
+		WithClock(clock).
+		WithMaxRetryAfter(10 * time.Second).
+		Get("/").
+		WithExpectedStatus(http.StatusOK).
+		WithRetry(2).
+		Error()
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Request.Error() error = %v, want a 429 StatusError", err)
+	}
+	if calls != 1 {
+		t.Errorf("Request.Error() calls = %v, want 1", calls)
+	}
+	if len(clock.sleeps) != 0 {
+		t.Errorf("Request.Error() slept %v, want no sleeps", clock.sleeps)
+	}
+}
+
+func TestRequest_RetryAfterPastDeadline(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	res, err := New().WithBaseURL(srv.URL).
+		Get("/").
+		WithContext(ctx).
+		WithRetryPolicy(RetryOnServerError).
+		WithRetry(3).
+		Do()
+	if err != nil {
+		t.Fatalf("Request.Do() error = %v", err)
+	}
+	defer res.Close()
+	if calls != 1 {
+		t.Errorf("Request.Do() calls = %v, want 1", calls)
+	}
+	if res.StatusCode() != http.StatusServiceUnavailable {
+		t.Errorf("Request.Do() status = %v, want 503", res.StatusCode())
+	}
+}
@@ -0,0 +1,348 @@
+// Package sigv4 signs requests made with an httpclient Client using AWS
+// Signature Version 4, for S3 compatible storage and other AWS style APIs
+package sigv4
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/s32x/httpclient"
+)
+
+const (
+	algorithm = "AWS4-HMAC-SHA256"
+
+	// timeFormat and dateFormat are the formats of the request time and the
+	// date used in the credential scope
+	timeFormat = "20060102T150405Z"
+	dateFormat = "20060102"
+
+	// emptyHash is the hex encoded SHA-256 hash of an empty payload
+	emptyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	// unsignedPayload is sent in place of the payload hash when the payload
+	// isn't signed
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// ignoredHeaders are never signed as proxies and the transport may change them
+var ignoredHeaders = map[string]bool{
+	"Authorization":   true,
+	"User-Agent":      true,
+	"X-Amzn-Trace-Id": true,
+	"Expect":          true,
+}
+
+// errUnhashableBody is returned when signing the payload of a request whose
+// body can't be read without consuming it
+var errUnhashableBody = errors.New("sigv4: can't hash a streamed body, use UnsignedPayload or StreamingPayload")
+
+// Credentials are the AWS credentials used to sign requests
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Only set for temporary credentials
+}
+
+// PayloadMode is how the payload of a request is signed
+type PayloadMode int
+
+const (
+	// SignedPayload includes the hash of the whole payload in the signature
+	SignedPayload PayloadMode = iota
+
+	// UnsignedPayload leaves the payload out of the signature
+	UnsignedPayload
+
+	// StreamingPayload sends the payload using aws-chunked encoding, signing
+	// each chunk as it's sent so that the payload never needs to be buffered
+	// to be hashed. The length of the payload must be known
+	StreamingPayload
+)
+
+// Signer signs requests using AWS Signature Version 4
+type Signer struct {
+	creds   Credentials
+	region  string
+	service string
+	payload PayloadMode
+	clock   httpclient.Clock
+}
+
+// New creates a new Signer that signs requests for the passed region and
+// service using the passed Credentials
+func New(creds Credentials, region, service string) *Signer {
+	return &Signer{
+		creds:   creds,
+		region:  region,
+		service: service,
+		clock:   httpclient.SystemClock,
+	}
+}
+
+// WithPayloadMode sets how the payload of requests are signed, SignedPayload
+// by default
+func (s *Signer) WithPayloadMode(mode PayloadMode) *Signer {
+	s.payload = mode
+	return s
+}
+
+// WithClock sets the Clock the time of signing is read from
+func (s *Signer) WithClock(clock httpclient.Clock) *Signer {
+	s.clock = clock
+	return s
+}
+
+// Middleware returns a Middleware that signs every attempt of a Request, so
+// that each one is signed with the time it's sent
+func (s *Signer) Middleware() httpclient.Middleware {
+	return func(next httpclient.Handler) httpclient.Handler {
+		return func(req *http.Request) (*httpclient.Response, error) {
+			req = req.Clone(req.Context())
+			if err := s.Sign(req, s.clock.Now()); err != nil {
+				return nil, err
+			}
+			return next(req)
+		}
+	}
+}
+
+// Sign signs the passed http Request as of the passed time, adding the
+// Authorization, X-Amz-Date and X-Amz-Content-Sha256 headers
+func (s *Signer) Sign(req *http.Request, now time.Time) error {
+	now = now.UTC()
+	req.Header.Del("Authorization")
+	req.Header.Set("X-Amz-Date", now.Format(timeFormat))
+	if s.creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.creds.SessionToken)
+	}
+
+	var payloadHash string
+	switch s.payload {
+	case UnsignedPayload:
+		payloadHash = unsignedPayload
+	case StreamingPayload:
+		if err := prepareStreaming(req); err != nil {
+			return err
+		}
+		payloadHash = streamingPayload
+	default:
+		var err error
+		if payloadHash, err = hashBody(req); err != nil {
+			return err
+		}
+	}
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders, canonicalHeaders := s.canonicalHeaders(req, nil)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s.canonicalPath(req.URL),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	signature := s.signature(now, canonicalRequest)
+	req.Header.Set("Authorization", algorithm+
+		" Credential="+s.creds.AccessKeyID+"/"+s.scope(now)+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+signature)
+
+	if s.payload == StreamingPayload {
+		s.streamChunks(req, now, signature)
+	}
+	return nil
+}
+
+// Presign returns the URL of the passed http Request with a signature in its
+// query string, valid for the passed duration from the passed time. Only the
+// Host header is signed, and S3 payloads are left unsigned
+func (s *Signer) Presign(req *http.Request, now time.Time, expires time.Duration) (*url.URL, error) {
+	now = now.UTC()
+	u := *req.URL
+	query := u.Query()
+	query.Set("X-Amz-Algorithm", algorithm)
+	query.Set("X-Amz-Credential", s.creds.AccessKeyID+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(timeFormat))
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expires/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if s.creds.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", s.creds.SessionToken)
+	}
+
+	payloadHash := unsignedPayload
+	if s.service != "s3" {
+		var err error
+		if payloadHash, err = hashBody(req); err != nil {
+			return nil, err
+		}
+	}
+	signedHeaders, canonicalHeaders := s.canonicalHeaders(req, map[string]bool{})
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s.canonicalPath(&u),
+		canonicalQuery(query),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	query.Set("X-Amz-Signature", s.signature(now, canonicalRequest))
+	u.RawQuery = canonicalQuery(query)
+	return &u, nil
+}
+
+// scope returns the credential scope of a signature made at the passed time
+func (s *Signer) scope(now time.Time) string {
+	return now.Format(dateFormat) + "/" + s.region + "/" + s.service + "/aws4_request"
+}
+
+// signature returns the signature of the passed canonical request
+func (s *Signer) signature(now time.Time, canonicalRequest string) string {
+	return s.sign(now, algorithm+"\n"+
+		now.Format(timeFormat)+"\n"+
+		s.scope(now)+"\n"+
+		hashHex([]byte(canonicalRequest)))
+}
+
+// sign returns the signature of the passed string to sign using the signing
+// key derived for the passed time
+func (s *Signer) sign(now time.Time, stringToSign string) string {
+	key := hmacSHA256([]byte("AWS4"+s.creds.SecretAccessKey), now.Format(dateFormat))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// canonicalPath returns the URI encoded path of the passed URL. Every service
+// other than S3 expects each segment to be encoded twice
+func (s *Signer) canonicalPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if unescaped, err := url.PathUnescape(seg); err == nil {
+			seg = unescaped
+		}
+		seg = escape(seg)
+		if s.service != "s3" {
+			seg = escape(seg)
+		}
+		segments[i] = seg
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery returns the passed query parameters URI encoded and sorted
+// by key and then value
+func canonicalQuery(query url.Values) string {
+	params := make([]string, 0, len(query))
+	for k, vs := range query {
+		for _, v := range vs {
+			params = append(params, escape(k)+"="+escape(v))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// canonicalHeaders returns the signed header names and the canonical headers
+// of the passed http Request. All headers other than ignoredHeaders are
+// signed, along with the Content-Length of streamed payloads, unless only is
+// set, in which case only the Host header and those in only are
+func (s *Signer) canonicalHeaders(req *http.Request, only map[string]bool) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	values := map[string][]string{"host": {host}}
+	if s.payload == StreamingPayload && only == nil {
+		values["content-length"] = []string{strconv.FormatInt(req.ContentLength, 10)}
+	}
+	for k, vs := range req.Header {
+		if ignoredHeaders[k] || (only != nil && !only[k]) {
+			continue
+		}
+		values[strings.ToLower(k)] = vs
+	}
+
+	names := make([]string, 0, len(values))
+	for k := range values {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, k := range names {
+		vs := make([]string, len(values[k]))
+		for i, v := range values[k] {
+			vs[i] = strings.Join(strings.Fields(v), " ")
+		}
+		b.WriteString(k + ":" + strings.Join(vs, ",") + "\n")
+	}
+	return strings.Join(names, ";"), b.String()
+}
+
+// hashBody returns the hex encoded SHA-256 hash of the body of the passed
+// http Request, reading it from GetBody so the Request can still be sent
+func hashBody(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return emptyHash, nil
+	}
+	if req.GetBody == nil {
+		return "", errUnhashableBody
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// escape URI encodes every byte of the passed string other than the
+// unreserved characters
+func escape(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexDigits[c>>4])
+		b.WriteByte(hexDigits[c&15])
+	}
+	return b.String()
+}
+
+// hashHex returns the hex encoded SHA-256 hash of the passed bytes
+func hashHex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of the passed data with the passed key
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
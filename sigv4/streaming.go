@@ -0,0 +1,129 @@
+package sigv4
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// streamingPayload is sent in place of the payload hash when the payload
+	// is sent as signed aws-chunked chunks
+	streamingPayload = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+
+	// chunkAlgorithm is the algorithm of the string signed for each chunk
+	chunkAlgorithm = "AWS4-HMAC-SHA256-PAYLOAD"
+)
+
+// ChunkSize is the size of the chunks a StreamingPayload is sent in
+var ChunkSize = 64 * 1024
+
+// errUnknownLength is returned when streaming a body whose length isn't known
+var errUnknownLength = errors.New("sigv4: can't stream a body of unknown length")
+
+// prepareStreaming sets the headers and length of the passed http Request for
+// its body to be sent using aws-chunked encoding
+func prepareStreaming(req *http.Request) error {
+	length := req.ContentLength
+	if req.Body == nil || req.Body == http.NoBody {
+		length = 0
+	} else if length <= 0 {
+		return errUnknownLength
+	}
+	req.Header.Set("Content-Encoding", "aws-chunked")
+	req.Header.Set("X-Amz-Decoded-Content-Length", strconv.FormatInt(length, 10))
+	req.ContentLength = encodedLength(length)
+	return nil
+}
+
+// encodedLength returns the length of a payload of the passed length once
+// encoded as signed chunks
+func encodedLength(length int64) int64 {
+	size := int64(ChunkSize)
+	full, rest := length/size, length%size
+	n := full * chunkLength(size)
+	if rest > 0 {
+		n += chunkLength(rest)
+	}
+	return n + chunkLength(0)
+}
+
+// chunkLength returns the encoded length of a chunk of the passed size
+func chunkLength(size int64) int64 {
+	return int64(len(strconv.FormatInt(size, 16))) +
+		int64(len(";chunk-signature=")) + 64 + 2 + size + 2
+}
+
+// streamChunks replaces the body of the passed http Request with one that
+// signs each chunk, starting from the passed seed signature
+func (s *Signer) streamChunks(req *http.Request, now time.Time, seed string) {
+	if req.Body == nil || req.Body == http.NoBody {
+		req.Body = io.NopCloser(&chunkReader{signer: s, now: now, prev: seed, body: http.NoBody})
+		return
+	}
+	req.Body = &chunkReader{signer: s, now: now, prev: seed, body: req.Body}
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return &chunkReader{signer: s, now: now, prev: seed, body: body}, nil
+		}
+	}
+}
+
+// chunkReader encodes the body it reads as signed aws-chunked chunks
+type chunkReader struct {
+	signer *Signer
+	now    time.Time
+	prev   string // The signature of the previous chunk
+	body   io.ReadCloser
+
+	buf  bytes.Buffer // Encoded bytes waiting to be read
+	done bool         // Whether the final chunk has been encoded
+}
+
+// Read reads the encoded chunks
+func (c *chunkReader) Read(p []byte) (int, error) {
+	for c.buf.Len() == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		chunk := make([]byte, ChunkSize)
+		n, err := io.ReadFull(c.body, chunk)
+		switch {
+		case err == io.EOF || err == io.ErrUnexpectedEOF:
+			if n > 0 {
+				c.encode(chunk[:n])
+			}
+			c.encode(nil)
+			c.done = true
+		case err != nil:
+			return 0, err
+		default:
+			c.encode(chunk)
+		}
+	}
+	return c.buf.Read(p)
+}
+
+// Close closes the underlying body
+func (c *chunkReader) Close() error { return c.body.Close() }
+
+// encode signs and encodes a single chunk
+func (c *chunkReader) encode(chunk []byte) {
+	c.prev = c.signer.sign(c.now, chunkAlgorithm+"\n"+
+		c.now.Format(timeFormat)+"\n"+
+		c.signer.scope(c.now)+"\n"+
+		c.prev+"\n"+
+		emptyHash+"\n"+
+		hashHex(chunk))
+	c.buf.WriteString(strconv.FormatInt(int64(len(chunk)), 16))
+	c.buf.WriteString(";chunk-signature=" + c.prev + "\r\n")
+	c.buf.Write(chunk)
+	c.buf.WriteString("\r\n")
+}
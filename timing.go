@@ -0,0 +1,135 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing is a breakdown of the time spent performing a Request, collected
+// using httptrace. Durations are zero for phases that didn't take place, such
+// as DNS lookups and connects when a connection was reused
+type Timing struct {
+	DNSLookup    time.Duration
+	Connect      time.Duration
+	TLSHandshake time.Duration
+	FirstByte    time.Duration // From the start of the attempt
+	Reused       bool          // Whether an idle connection was reused
+	RemoteAddr   string
+
+	// Total is the time until the response headers were received. For an
+	// attempt it's measured from the start of that attempt, while for a
+	// whole Request it's measured from the start of the first attempt, so
+	// it also includes the backoff waits between attempts
+	Total time.Duration
+
+	// Attempts holds the Timing of every attempt when the Timing describes
+	// a whole Request, the last attempt being the one described above
+	Attempts []Timing
+}
+
+// Timing returns the Timing of the Request that produced the Response. Within
+// a Middleware it only describes the current attempt
+func (r *Response) Timing() Timing { return r.timing }
+
+// timingKey is the context key holding the tracer of an attempt
+type timingKey struct{}
+
+// tracer collects the Timing of a single attempt
+type tracer struct {
+	mu                                   sync.Mutex
+	start, dnsStart, connStart, tlsStart time.Time
+	timing                               Timing
+}
+
+// newTracer returns a tracer for an attempt starting now
+func newTracer() *tracer { return &tracer{start: time.Now()} }
+
+// withTracer returns a copy of the passed context holding the tracer
+func withTracer(ctx context.Context, t *tracer) context.Context {
+	return context.WithValue(ctx, timingKey{}, t)
+}
+
+// traceRequest returns the passed http Request with an httptrace ClientTrace
+// reporting to the tracer held in its context, if there is one
+func traceRequest(req *http.Request) *http.Request {
+	t, ok := req.Context().Value(timingKey{}).(*tracer)
+	if !ok {
+		return req
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), t.clientTrace()))
+}
+
+// attemptTiming returns the Timing collected so far for the attempt the
+// passed http Request belongs to
+func attemptTiming(req *http.Request) Timing {
+	t, ok := req.Context().Value(timingKey{}).(*tracer)
+	if !ok {
+		return Timing{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	timing := t.timing
+	timing.Total = time.Since(t.start)
+	return timing
+}
+
+// clientTrace returns the httptrace ClientTrace that reports to the tracer
+func (t *tracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.record(func() { t.dnsStart = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.record(func() { t.timing.DNSLookup = time.Since(t.dnsStart) })
+		},
+		ConnectStart: func(string, string) {
+			t.record(func() {
+				if t.connStart.IsZero() {
+					t.connStart = time.Now()
+				}
+			})
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				t.record(func() { t.timing.Connect = time.Since(t.connStart) })
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.record(func() { t.tlsStart = time.Now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.record(func() { t.timing.TLSHandshake = time.Since(t.tlsStart) })
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.record(func() {
+				t.timing.Reused = info.Reused
+				if info.Conn != nil {
+					t.timing.RemoteAddr = info.Conn.RemoteAddr().String()
+				}
+			})
+		},
+		GotFirstResponseByte: func() {
+			t.record(func() { t.timing.FirstByte = time.Since(t.start) })
+		},
+	}
+}
+
+// record applies the passed change to the tracer while holding its lock, as
+// the httptrace hooks may be called concurrently
+func (t *tracer) record(change func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	change()
+}
+
+// done marks the attempt as finished and returns its Timing
+func (t *tracer) done() Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timing.Total = time.Since(t.start)
+	return t.timing
+}
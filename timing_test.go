@@ -0,0 +1,85 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponse_Timing(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	c := New().WithClient(srv.Client()).WithBaseURL(srv.URL)
+
+	tests := []struct {
+		name       string
+		wantReused bool
+	}{
+		{name: "new connection", wantReused: false},
+		{name: "reused connection", wantReused: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := c.Get("/").Do()
+			if err != nil {
+				t.Fatalf("Request.Do() error = %v", err)
+			}
+			res.Bytes()
+			res.Close()
+
+			timing := res.Timing()
+			if timing.Reused != tt.wantReused {
+				t.Errorf("Timing.Reused = %v, want %v", timing.Reused, tt.wantReused)
+			}
+			if !tt.wantReused && (timing.Connect <= 0 || timing.TLSHandshake <= 0) {
+				t.Errorf("Timing = %+v, want connect and TLS handshake durations", timing)
+			}
+			if timing.RemoteAddr != srv.Listener.Addr().String() {
+				t.Errorf("Timing.RemoteAddr = %v, want %v", timing.RemoteAddr, srv.Listener.Addr())
+			}
+			if timing.FirstByte <= 0 || timing.Total < timing.FirstByte {
+				t.Errorf("Timing = %+v, want 0 < FirstByte <= Total", timing)
+			}
+			if len(timing.Attempts) != 1 {
+				t.Errorf("len(Timing.Attempts) = %v, want 1", len(timing.Attempts))
+			}
+		})
+	}
+}
+
+func TestResponse_Timing_Attempts(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	res, err := New().WithBaseURL(srv.URL).
+		WithClock(&fakeClock{}).
+		Get("/").
+		WithRetryPolicy(RetryOnServerError).
+		WithRetry(3).
+		Do()
+	if err != nil {
+		t.Fatalf("Request.Do() error = %v", err)
+	}
+	defer res.Close()
+
+	timing := res.Timing()
+	if len(timing.Attempts) != 3 {
+		t.Fatalf("len(Timing.Attempts) = %v, want 3", len(timing.Attempts))
+	}
+	if timing.Attempts[0].Reused || !timing.Attempts[2].Reused {
+		t.Errorf("Timing.Attempts = %+v, want later attempts to reuse the connection", timing.Attempts)
+	}
+	for _, a := range timing.Attempts {
+		if a.Total > timing.Total {
+			t.Errorf("attempt Total %v exceeds Request Total %v", a.Total, timing.Total)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// errNoTLSTransport is returned by Requests when TLS settings are set on a
+// Client whose transport isn't an http Transport they can be applied to
+var errNoTLSTransport = errors.New("httpclient: TLS settings need the transport to be an *http.Transport")
+
+// tlsSettings are the TLS settings applied to a Clients transport
+type tlsSettings struct {
+	certificate *certificateSource
+	rootCAs     *x509.CertPool
+	minVersion  uint16
+	pins        *pinSet
+}
+
+// WithClientCertificate sets the PEM encoded certificate and private key the
+// Client presents to servers that ask for one
+func (c *Client) WithClientCertificate(certPEM, keyPEM []byte) *Client {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		c.err = fmt.Errorf("loading client certificate: %w", err)
+		return c
+	}
+	return c.withTLS(func(s *tlsSettings) {
+		s.certificate = &certificateSource{cert: &cert}
+	})
+}
+
+// WithCertificateFiles sets the PEM encoded certificate and private key files
+// the Client presents to servers that ask for one. The files are reloaded
+// when they change on disk, so rotated certificates are used without
+// recreating the Client
+func (c *Client) WithCertificateFiles(certFile, keyFile string) *Client {
+	src := &certificateSource{certFile: certFile, keyFile: keyFile}
+	if _, err := src.get(); err != nil {
+		c.err = err
+		return c
+	}
+	return c.withTLS(func(s *tlsSettings) { s.certificate = src })
+}
+
+// WithRootCAs sets the certificate authorities the Client trusts to verify
+// servers, in place of the system roots
+func (c *Client) WithRootCAs(pool *x509.CertPool) *Client {
+	return c.withTLS(func(s *tlsSettings) { s.rootCAs = pool })
+}
+
+// WithMinTLSVersion sets the minimum TLS version the Client accepts, such as
+// tls.VersionTLS13
+func (c *Client) WithMinTLSVersion(version uint16) *Client {
+	return c.withTLS(func(s *tlsSettings) { s.minVersion = version })
+}
+
+// withTLS updates a copy of the Clients TLS settings and applies them to its
+// transport
+func (c *Client) withTLS(update func(s *tlsSettings)) *Client {
+	s := &tlsSettings{}
+	if c.tls != nil {
+		*s = *c.tls
+	}
+	update(s)
+	c.tls = s
+	c.applyTLS()
+	return c
+}
+
+// applyTLS applies the Clients TLS settings to a copy of its transport, so
+// they hold whichever transport is set and in whatever order
+func (c *Client) applyTLS() {
+	if c.tls == nil {
+		return
+	}
+	if c.err == errNoTLSTransport {
+		c.err = nil
+	}
+	var t *http.Transport
+	switch rt := c.client.Transport.(type) {
+	case nil:
+		t = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		t = rt.Clone()
+	default:
+		c.err = errNoTLSTransport
+		return
+	}
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{}
+	}
+	c.tls.apply(t.TLSClientConfig)
+	c.client.Transport = t
+}
+
+// apply applies the TLS settings to the passed tls Config
+func (s *tlsSettings) apply(cfg *tls.Config) {
+	if s.certificate != nil {
+		cfg.Certificates = nil
+		cfg.GetClientCertificate = s.certificate.getClientCertificate
+	}
+	if s.rootCAs != nil {
+		cfg.RootCAs = s.rootCAs
+	}
+	if s.minVersion != 0 {
+		cfg.MinVersion = s.minVersion
+	}
+	if s.pins != nil {
+		cfg.VerifyConnection = s.pins.verifyConnection
+	}
+}
+
+// certificateSource provides a client certificate, either fixed or loaded
+// from files that are reloaded when they change
+type certificateSource struct {
+	certFile, keyFile string
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	modified [2]time.Time // When the certificate and key files were loaded
+}
+
+// getClientCertificate returns the certificate for a tls Config
+func (s *certificateSource) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return s.get()
+}
+
+// get returns the certificate, reloading its files if they've changed since
+// they were last loaded
+func (s *certificateSource) get() (*tls.Certificate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.certFile == "" {
+		return s.cert, nil
+	}
+
+	certInfo, err := os.Stat(s.certFile)
+	if err != nil {
+		return s.loaded(err)
+	}
+	keyInfo, err := os.Stat(s.keyFile)
+	if err != nil {
+		return s.loaded(err)
+	}
+	modified := [2]time.Time{certInfo.ModTime(), keyInfo.ModTime()}
+	if s.cert != nil && modified[0].Equal(s.modified[0]) && modified[1].Equal(s.modified[1]) {
+		return s.cert, nil
+	}
+	cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		return s.loaded(err)
+	}
+	s.cert, s.modified = &cert, modified
+	return s.cert, nil
+}
+
+// loaded returns the last loaded certificate when reloading fails with the
+// passed error, such as while the files are part way through being rotated
+func (s *certificateSource) loaded(err error) (*tls.Certificate, error) {
+	if s.cert != nil {
+		return s.cert, nil
+	}
+	return nil, fmt.Errorf("loading client certificate: %w", err)
+}
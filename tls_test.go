@@ -0,0 +1,210 @@
+package httpclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCert is a certificate and key generated for tests
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCert generates a certificate for the passed common name, signed by
+// the passed parent or self-signed as a CA when nil
+func newTestCert(t *testing.T, cn string, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// newMutualTLSServer starts a server using a certificate signed by the
+// passed CA that requires clients to present one too, and responds with the
+// common name of the client certificate
+func newMutualTLSServer(t *testing.T, ca *testCert) *httptest.Server {
+	t.Helper()
+	leaf := newTestCert(t, "server", ca)
+	cert, err := tls.X509KeyPair(leaf.certPEM, leaf.keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // Quieten rejected handshakes
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient_MutualTLS(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	srv := newMutualTLSServer(t, ca)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	client := newTestCert(t, "client", ca)
+
+	tests := []struct {
+		name    string
+		client  func() *Client
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "certificate",
+			client: func() *Client {
+				return New().WithRootCAs(pool).WithClientCertificate(client.certPEM, client.keyPEM)
+			},
+			want: "client",
+		},
+		{
+			name: "settings kept by a later transport",
+			client: func() *Client {
+				return New().WithClientCertificate(client.certPEM, client.keyPEM).
+					WithRootCAs(pool).
+					WithTransport(&http.Transport{}).
+					WithMinTLSVersion(tls.VersionTLS12)
+			},
+			want: "client",
+		},
+		{
+			name:    "no certificate",
+			client:  func() *Client { return New().WithRootCAs(pool) },
+			wantErr: true,
+		},
+		{
+			name:    "untrusted server",
+			client:  func() *Client { return New().WithClientCertificate(client.certPEM, client.keyPEM) },
+			wantErr: true,
+		},
+		{
+			name:    "invalid certificate",
+			client:  func() *Client { return New().WithRootCAs(pool).WithClientCertificate(client.certPEM, nil) },
+			wantErr: true,
+		},
+		{
+			name: "transport that isn't an http Transport",
+			client: func() *Client {
+				return New().WithRootCAs(pool).WithTransport(roundTripperFunc(http.DefaultTransport.RoundTrip))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.client().WithBaseURL(srv.URL).Get("/").String()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Request.String() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Request.String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_WithProxy_TLS(t *testing.T) {
+	pool := x509.NewCertPool()
+	c := New().WithRootCAs(pool).WithMinTLSVersion(tls.VersionTLS13).WithProxy("socks5", "127.0.0.1:1080")
+	transport, ok := c.Client().Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Client().Transport = %T, want *http.Transport", c.Client().Transport)
+	}
+	if transport.Proxy == nil {
+		t.Error("proxy transport replaced")
+	}
+	if cfg := transport.TLSClientConfig; cfg == nil || cfg.RootCAs != pool || cfg.MinVersion != tls.VersionTLS13 {
+		t.Errorf("TLSClientConfig = %+v, want the Client TLS settings", cfg)
+	}
+}
+
+func TestClient_WithCertificateFiles_Reload(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	srv := newMutualTLSServer(t, ca)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	write := func(cert *testCert, modified time.Time) {
+		for file, b := range map[string][]byte{certFile: cert.certPEM, keyFile: cert.keyPEM} {
+			if err := os.WriteFile(file, b, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(file, modified, modified); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	write(newTestCert(t, "first", ca), time.Now().Add(-time.Minute))
+
+	c := New().WithBaseURL(srv.URL).WithRootCAs(pool).WithCertificateFiles(certFile, keyFile)
+	for _, want := range []string{"first", "second"} {
+		got, err := c.Get("/").String()
+		if err != nil {
+			t.Fatalf("Request.String() error = %v", err)
+		}
+		if got != want {
+			t.Errorf("Request.String() = %q, want %q", got, want)
+		}
+
+		// Rotate the certificate, closing connections so it's presented
+		write(newTestCert(t, "second", ca), time.Now())
+		c.Client().CloseIdleConnections()
+	}
+}
@@ -0,0 +1,182 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TokenExpiryDelta is how long before its expiry a cached Token is treated as
+// expired, so that it isn't used for a request that arrives after it expires
+var TokenExpiryDelta = 10 * time.Second
+
+// errNoToken is returned when a TokenSource returns neither a Token nor an
+// error
+var errNoToken = errors.New("token source returned no token")
+
+// Token is an access token used to authenticate requests
+type Token struct {
+	AccessToken string
+	TokenType   string    // The authorization scheme, Bearer when empty
+	Expiry      time.Time // When the Token expires, or zero if it never does
+}
+
+// authorization returns the Authorization header value for the Token
+func (t *Token) authorization() string {
+	typ := t.TokenType
+	if typ == "" {
+		typ = "Bearer"
+	}
+	return typ + " " + t.AccessToken
+}
+
+// valid reports whether the Token can still be used at the passed time
+func (t *Token) valid(now time.Time) bool {
+	return t != nil && t.AccessToken != "" &&
+		(t.Expiry.IsZero() || now.Add(TokenExpiryDelta).Before(t.Expiry))
+}
+
+// TokenSource provides Tokens used to authenticate requests
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// TokenSourceFunc is a function that implements TokenSource
+type TokenSourceFunc func(ctx context.Context) (*Token, error)
+
+// Token calls the function
+func (f TokenSourceFunc) Token(ctx context.Context) (*Token, error) { return f(ctx) }
+
+// WithTokenSource sets a TokenSource that authenticates every attempt of every
+// Request created by the Client, replacing any Authorization set with
+// WithBasicAuth(...) or WithBearerToken(...). Tokens are cached until they
+// expire, and concurrent refreshes share a single call to the TokenSource. A
+// request answered with a 401 is retried once with a fresh Token
+func (c *Client) WithTokenSource(ts TokenSource) *Client {
+	c.tokens = &tokenCache{source: ts}
+	return c
+}
+
+// tokenCache caches the Token from a TokenSource, collapsing concurrent
+// refreshes into a single call
+type tokenCache struct {
+	source TokenSource
+
+	mu      sync.Mutex
+	token   *Token
+	refresh *tokenRefresh // The refresh in progress, if any
+}
+
+// tokenRefresh is a single call to a TokenSource shared by all callers
+// waiting on it
+type tokenRefresh struct {
+	done  chan struct{}
+	token *Token
+	err   error
+}
+
+// get returns the cached Token if it's still valid at the passed time,
+// otherwise waiting for a fresh one
+func (c *tokenCache) get(ctx context.Context, now time.Time) (*Token, error) {
+	c.mu.Lock()
+	if c.token.valid(now) {
+		defer c.mu.Unlock()
+		return c.token, nil
+	}
+	refresh := c.refresh
+	if refresh == nil {
+		refresh = &tokenRefresh{done: make(chan struct{})}
+		c.refresh = refresh
+
+		// The refresh is shared so it mustn't be cancelled with the Request
+		// that happened to start it
+		go c.fetch(context.WithoutCancel(ctx), refresh)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-refresh.done:
+		return refresh.token, refresh.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetch performs the passed refresh, caching the Token on success
+func (c *tokenCache) fetch(ctx context.Context, refresh *tokenRefresh) {
+	tok, err := c.source.Token(ctx)
+	if err == nil && (tok == nil || tok.AccessToken == "") {
+		err = errNoToken
+	}
+	if err != nil {
+		refresh.err = fmt.Errorf("fetching token: %w", err)
+	} else {
+		refresh.token = tok
+	}
+
+	c.mu.Lock()
+	if err == nil {
+		c.token = tok
+	}
+	c.refresh = nil
+	c.mu.Unlock()
+	close(refresh.done)
+}
+
+// invalidate drops the passed Token from the cache, unless it has already
+// been replaced
+func (c *tokenCache) invalidate(tok *Token) {
+	c.mu.Lock()
+	if c.token == tok {
+		c.token = nil
+	}
+	c.mu.Unlock()
+}
+
+// authenticate wraps the passed Handler, authenticating every request with a
+// Token from the Requests TokenSource and retrying once with a fresh Token
+// when the server responds with a 401
+func (r *Request) authenticate(next Handler) Handler {
+	if r.tokens == nil {
+		return next
+	}
+	clock := r.clock
+	if clock == nil {
+		clock = SystemClock
+	}
+	return func(req *http.Request) (*Response, error) {
+		res, tok, err := r.sendWithToken(next, req, clock)
+		if err != nil || res.res.StatusCode != http.StatusUnauthorized || !replayable(req) {
+			return res, err
+		}
+
+		// The Token was rejected so drop it and try once more with a new one
+		r.tokens.invalidate(tok)
+		if req, err = rewind(req); err != nil {
+			discard(res.res)
+			return nil, err
+		}
+		retry, tok, err := r.sendWithToken(next, req, clock)
+		if tok == nil {
+			// Report the original 401 rather than failing to get a new Token
+			return res, nil
+		}
+		discard(res.res)
+		return retry, err
+	}
+}
+
+// sendWithToken applies a Token to a copy of the passed http Request and
+// sends it with the passed Handler, returning the Token used. The Token is
+// nil when one couldn't be fetched
+func (r *Request) sendWithToken(next Handler, req *http.Request, clock Clock) (*Response, *Token, error) {
+	tok, err := r.tokens.get(req.Context(), clock.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	res, err := next(withCredential(req, credential{APIKeyInHeader, "Authorization", tok.authorization()}))
+	return res, tok, err
+}
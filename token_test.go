@@ -0,0 +1,167 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingTokenSource returns a new Token, numbered by how many it has
+// returned, that expires after the passed duration
+func countingTokenSource(clock Clock, ttl time.Duration, calls *int32) TokenSource {
+	return TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+		n := atomic.AddInt32(calls, 1)
+		return &Token{AccessToken: fmt.Sprintf("token-%d", n), Expiry: clock.Now().Add(ttl)}, nil
+	})
+}
+
+func TestTokenSource_Cache(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	var calls int32
+	clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := New().WithBaseURL(srv.URL).WithClock(clock).
+		WithTokenSource(countingTokenSource(clock, time.Minute, &calls))
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		want    string
+	}{
+		{"first request fetches", 0, "Bearer token-1"},
+		{"cached", 30 * time.Second, "Bearer token-1"},
+		{"refreshed before expiry", 25 * time.Second, "Bearer token-2"},
+		{"cached again", time.Second, "Bearer token-2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.Sleep(context.Background(), tt.advance)
+			got, err := c.Get("/").String()
+			if err != nil {
+				t.Fatalf("Request.String() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Authorization = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenSource_Concurrent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	var calls int32
+	release := make(chan struct{})
+	c := New().WithBaseURL(srv.URL).
+		WithTokenSource(TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return &Token{AccessToken: "token"}, nil
+		}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.Get("/").Error(); err != nil {
+				t.Errorf("Request.Error() error = %v", err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("TokenSource called %d times, want 1", calls)
+	}
+}
+
+func TestTokenSource_Unauthorized(t *testing.T) {
+	tests := []struct {
+		name      string
+		valid     string // The only token the server accepts
+		wantCalls int32
+		wantSent  []string
+		wantErr   bool
+	}{
+		{
+			name:      "accepted",
+			valid:     "token-1",
+			wantCalls: 1,
+			wantSent:  []string{"Bearer token-1"},
+		},
+		{
+			name:      "retried with fresh token",
+			valid:     "token-2",
+			wantCalls: 2,
+			wantSent:  []string{"Bearer token-1", "Bearer token-2"},
+		},
+		{
+			name:      "retried only once",
+			valid:     "token-3",
+			wantCalls: 2,
+			wantSent:  []string{"Bearer token-1", "Bearer token-2"},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sent = append(sent, r.Header.Get("Authorization"))
+				if r.Header.Get("Authorization") != "Bearer "+tt.valid {
+					w.WriteHeader(http.StatusUnauthorized)
+				}
+			}))
+			defer srv.Close()
+
+			var calls int32
+			err := New().WithBaseURL(srv.URL).
+				WithTokenSource(countingTokenSource(SystemClock, time.Hour, &calls)).
+				Post("/").
+				WithBody(bytes.NewBufferString("body")).
+				WithExpectedStatus(http.StatusOK).
+				Error()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Request.Error() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("TokenSource called %d times, want %d", calls, tt.wantCalls)
+			}
+			if strings.Join(sent, ",") != strings.Join(tt.wantSent, ",") {
+				t.Errorf("sent %v, want %v", sent, tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestTokenSource_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request sent without a token")
+	}))
+	defer srv.Close()
+
+	errAuth := errors.New("auth server down")
+	err := New().WithBaseURL(srv.URL).
+		WithTokenSource(TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+			return nil, errAuth
+		})).
+		Get("/").
+		Error()
+	if !errors.Is(err, errAuth) {
+		t.Errorf("Request.Error() error = %v, want %v", err, errAuth)
+	}
+}
@@ -0,0 +1,111 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// URLJoinMode is how a Requests path is joined to the Clients base URL
+type URLJoinMode int
+
+const (
+	// JoinAppend appends the path to the base URLs path with a single slash
+	// between them, so https://api.example.com/v1/ and https://api.example.com/v1
+	// both join with /users or users to give https://api.example.com/v1/users
+	JoinAppend URLJoinMode = iota
+
+	// JoinResolve resolves the path as a reference relative to the base URL
+	// (RFC 3986 section 5), like a link in a web page. Paths starting with a
+	// slash replace the base URLs path, and other paths replace its last
+	// segment unless it ends with a slash
+	JoinResolve
+)
+
+// WithURLJoin sets how the paths of Requests created by the Client are joined
+// to its base URL, JoinAppend by default. Whatever the mode, absolute URLs
+// passed as a path replace the base URL, and the query of the base URL is
+// kept before that of the path. Scheme relative URLs like //host/path only
+// replace the base URLs host with JoinResolve, JoinAppend appends them as a
+// path
+func (c *Client) WithURLJoin(mode URLJoinMode) *Client {
+	c.urlJoin = mode
+	return c
+}
+
+// errInvalidBaseURL is wrapped by the errors returned for invalid base URLs
+var errInvalidBaseURL = errors.New("invalid base URL")
+
+// validateBaseURL returns an error if the passed base URL isn't an absolute
+// http or https URL
+func validateBaseURL(baseURL string) error {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInvalidBaseURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w %q: scheme must be http or https", errInvalidBaseURL, baseURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%w %q: missing host", errInvalidBaseURL, baseURL)
+	}
+	if u.Fragment != "" {
+		return fmt.Errorf("%w %q: fragments aren't sent", errInvalidBaseURL, baseURL)
+	}
+	return nil
+}
+
+// joinURL joins the passed path, which may have a query or be an absolute
+// URL, to the passed base URL using the passed mode
+func joinURL(baseURL, path string, mode URLJoinMode) (*url.URL, error) {
+	ref, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	if baseURL == "" || ref.IsAbs() {
+		return ref, nil
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Host != "" && mode == JoinResolve {
+		return base.ResolveReference(ref), nil
+	}
+
+	var u *url.URL
+	switch mode {
+	case JoinResolve:
+		u = base.ResolveReference(&url.URL{Path: ref.Path, RawPath: ref.RawPath})
+	default:
+		u = new(url.URL)
+		*u = *base
+		p := ref.EscapedPath()
+		if strings.HasPrefix(path, "//") {
+			// Keep paths starting with // on the base host rather than
+			// treating them as scheme relative URLs for another host
+			p, _, _ = strings.Cut(path, "#")
+			p, _, _ = strings.Cut(p, "?")
+		}
+		escaped := base.EscapedPath()
+		if p != "" {
+			escaped = strings.TrimSuffix(escaped, "/") + "/" + strings.TrimPrefix(p, "/")
+		}
+		if u.Path, err = url.PathUnescape(escaped); err != nil {
+			return nil, err
+		}
+		u.RawPath = escaped
+	}
+
+	var queries []string
+	for _, q := range []string{base.RawQuery, ref.RawQuery} {
+		if q != "" {
+			queries = append(queries, q)
+		}
+	}
+	u.RawQuery = strings.Join(queries, "&")
+	u.ForceQuery = false
+	u.Fragment, u.RawFragment = "", ""
+	return u, nil
+}
@@ -0,0 +1,127 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJoinURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    string
+		path    string
+		mode    URLJoinMode
+		want    string
+		wantErr bool
+	}{
+		{name: "append slash slash", base: "https://api.example.com/x/", path: "/y", want: "https://api.example.com/x/y"},
+		{name: "append slash none", base: "https://api.example.com/x/", path: "y", want: "https://api.example.com/x/y"},
+		{name: "append none slash", base: "https://api.example.com/x", path: "/y", want: "https://api.example.com/x/y"},
+		{name: "append none none", base: "https://api.example.com/x", path: "y", want: "https://api.example.com/x/y"},
+		{name: "append host only", base: "https://api.example.com", path: "y", want: "https://api.example.com/y"},
+		{name: "append host slash", base: "https://api.example.com/", path: "/y/", want: "https://api.example.com/y/"},
+		{name: "append empty path", base: "https://api.example.com/x/", path: "", want: "https://api.example.com/x/"},
+		{name: "append root path", base: "https://api.example.com/x", path: "/", want: "https://api.example.com/x/"},
+		{name: "append escaped", base: "https://api.example.com/x", path: "/a%2Fb", want: "https://api.example.com/x/a%2Fb"},
+		{name: "append queries", base: "https://api.example.com/x?key=k", path: "/y?a=1", want: "https://api.example.com/x/y?key=k&a=1"},
+		{name: "append query only", base: "https://api.example.com/x", path: "?a=1", want: "https://api.example.com/x?a=1"},
+		{name: "resolve slash slash", base: "https://api.example.com/x/", path: "/y", mode: JoinResolve, want: "https://api.example.com/y"},
+		{name: "resolve slash none", base: "https://api.example.com/x/", path: "y", mode: JoinResolve, want: "https://api.example.com/x/y"},
+		{name: "resolve none slash", base: "https://api.example.com/x", path: "/y", mode: JoinResolve, want: "https://api.example.com/y"},
+		{name: "resolve none none", base: "https://api.example.com/x", path: "y", mode: JoinResolve, want: "https://api.example.com/y"},
+		{name: "resolve host only", base: "https://api.example.com", path: "y", mode: JoinResolve, want: "https://api.example.com/y"},
+		{name: "resolve empty path", base: "https://api.example.com/x/z", path: "", mode: JoinResolve, want: "https://api.example.com/x/z"},
+		{name: "resolve escaped", base: "https://api.example.com/x/", path: "a%2Fb", mode: JoinResolve, want: "https://api.example.com/x/a%2Fb"},
+		{name: "resolve queries", base: "https://api.example.com/x?key=k", path: "y?a=1", mode: JoinResolve, want: "https://api.example.com/y?key=k&a=1"},
+		{name: "absolute", base: "https://api.example.com/x/", path: "http://other.example.com/y?a=1", want: "http://other.example.com/y?a=1"},
+		{name: "absolute resolve", base: "https://api.example.com/x/", path: "http://other.example.com/y", mode: JoinResolve, want: "http://other.example.com/y"},
+		{name: "scheme relative", base: "https://api.example.com/x/", path: "//other.example.com/y?a=1", want: "https://api.example.com/x//other.example.com/y?a=1"},
+		{name: "scheme relative host only", base: "https://api.example.com", path: "//other.example.com", want: "https://api.example.com//other.example.com"},
+		{name: "scheme relative resolve", base: "https://api.example.com/x/", path: "//other.example.com/y", mode: JoinResolve, want: "https://other.example.com/y"},
+		{name: "no base", path: "https://api.example.com/y", want: "https://api.example.com/y"},
+		{name: "invalid path", base: "https://api.example.com", path: "/%zz", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := joinURL(tt.base, tt.path, tt.mode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("joinURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("joinURL() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateBaseURL(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		wantErr bool
+	}{
+		{"https://api.example.com", false},
+		{"http://localhost:8080/v1/?key=k", false},
+		{"api.example.com", true},
+		{"/v1", true},
+		{"ftp://example.com", true},
+		{"https://", true},
+		{"https://api.example.com/#x", true},
+		{"https://api example.com", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.baseURL, func(t *testing.T) {
+			if err := validateBaseURL(tt.baseURL); (err != nil) != tt.wantErr {
+				t.Errorf("validateBaseURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_WithURLJoin(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.RequestURI()))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		client  *Client
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "append", client: New().WithBaseURL(srv.URL + "/v1/"), path: "/users", want: "/v1/users"},
+		{
+			name:   "resolve",
+			client: New().WithBaseURL(srv.URL + "/v1/").WithURLJoin(JoinResolve),
+			path:   "/users",
+			want:   "/users",
+		},
+		{name: "absolute", client: New().WithBaseURL("https://unused.example.com/v1"), path: srv.URL + "/other", want: "/other"},
+		{name: "invalid base URL", client: New().WithBaseURL("api.example.com"), path: "/users", wantErr: true},
+		{
+			name:   "invalid base URL replaced",
+			client: New().WithBaseURL("api.example.com").WithBaseURL(srv.URL),
+			path:   "/users",
+			want:   "/users",
+		},
+		{
+			name:    "other errors kept",
+			client:  New().WithBaseURL("api.example.com").WithClientCertificate(nil, nil).WithBaseURL(srv.URL),
+			path:    "/users",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.client.Get(tt.path).String()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Request.String() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("request URI = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}